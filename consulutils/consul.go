@@ -16,14 +16,11 @@ import (
 	"regexp"
 	"strings"
 	"sync"
-	"time"
 
 	"github.com/hashicorp/consul/api"
 	"github.com/miebyte/goutils/internal/innerlog"
 	"github.com/miebyte/goutils/internal/share"
-	"github.com/pkg/errors"
 	"golang.org/x/sync/singleflight"
-	"gopkg.in/mgo.v2/bson"
 )
 
 var (
@@ -42,8 +39,10 @@ type RegisteredService struct {
 
 type Client struct {
 	*api.Client
-	sg       singleflight.Group
-	services []RegisteredService
+	sg singleflight.Group
+
+	mu       sync.Mutex
+	services []*registration
 }
 
 func init() {
@@ -206,64 +205,5 @@ func (c *Client) RegisterServiceWithTag(serviceName string, address string, tag
 }
 
 func (c *Client) RegisterServiceWithTags(serviceName string, address string, tags []string) error {
-	if !validServiceName(serviceName) {
-		return errors.New("Invalid service name")
-	}
-
-	// parse host and port from address
-	ip, err := net.ResolveTCPAddr("tcp", address)
-	if err != nil {
-		return err
-	}
-
-	hostname, err := os.Hostname()
-	if err != nil {
-		hostname = bson.NewObjectId().Hex()
-	}
-	hostname = strings.ReplaceAll(hostname, ".", "-")
-	serviceID := fmt.Sprintf("%s-%d-%s", serviceName, ip.Port, hostname)
-	checkID := fmt.Sprintf("service:%s", serviceID)
-
-	regis := &api.AgentServiceRegistration{
-		ID:      serviceID,
-		Name:    serviceName,
-		Port:    ip.Port,
-		Address: ip.IP.String(),
-		Tags:    tags,
-		Check: &api.AgentServiceCheck{
-			CheckID:                        checkID,
-			Name:                           serviceID,
-			TCP:                            address,
-			Interval:                       (time.Second * 10).String(),
-			Status:                         "passing",
-			DeregisterCriticalServiceAfter: "10m",
-		},
-	}
-	if err := c.Agent().ServiceRegister(regis); err != nil {
-		return errors.Errorf("initial register service '%s' host to consul error: %s", serviceName, err.Error())
-	}
-
-	c.services = append(c.services, RegisteredService{ServiceID: serviceID, CheckID: checkID})
-	return nil
-}
-
-func (c *Client) deregisterServiceAndCheck(serviceID, checkID string) (reterr error) {
-	if err := c.Agent().CheckDeregister(checkID); err != nil {
-		reterr = errors.Wrap(err, "Deregister check")
-	}
-
-	if err := c.Agent().ServiceDeregister(serviceID); err != nil {
-		reterr = errors.Wrap(err, "Deregister service")
-	}
-	return
-}
-
-func (c *Client) Close() {
-	for _, r := range c.services {
-		if err := c.deregisterServiceAndCheck(r.ServiceID, r.CheckID); err != nil {
-			innerlog.Logger.Errorf("Deregister service %v error: %v", r.ServiceID, err)
-		} else {
-			innerlog.Logger.Infof("Deregistered service: %v success", r.ServiceID)
-		}
-	}
+	return c.RegisterServiceWithOptions(serviceName, address, WithRegisterTags(tags...))
 }
@@ -0,0 +1,239 @@
+// File:		kv.go
+// Created by:	Hoven
+// Created on:	2026-10-17
+//
+// This file is part of the Example Project.
+//
+// (c) 2024 Example Corp. All rights reserved.
+
+package consulutils
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/miebyte/goutils/internal/innerlog"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrKeyNotFound = errors.New("consul key not found")
+	ErrCASConflict = errors.New("consul key modified concurrently")
+)
+
+var (
+	defaultKVWatchWaitTime = time.Minute * 5
+	kvWatchMinBackoff      = time.Second
+	kvWatchMaxBackoff      = time.Second * 30
+)
+
+// KVEntry consul KV 中解码后的值
+type KVEntry[T any] struct {
+	Key         string
+	Value       T
+	ModifyIndex uint64
+	// Session 持有该 key 的 session, 未被锁定时为空
+	Session string
+}
+
+// encodeValue 将 value 编码为 JSON, []byte 与 string 类型直接写入原始内容
+func encodeValue[T any](value T) ([]byte, error) {
+	switch v := any(value).(type) {
+	case []byte:
+		return v, nil
+	case string:
+		return []byte(v), nil
+	}
+
+	b, err := json.Marshal(value)
+	if err != nil {
+		return nil, errors.Wrap(err, "marshal value")
+	}
+	return b, nil
+}
+
+// decodeValue 与 encodeValue 对应, []byte 与 string 类型直接使用原始内容
+func decodeValue[T any](data []byte) (T, error) {
+	var value T
+	switch v := any(&value).(type) {
+	case *[]byte:
+		*v = data
+		return value, nil
+	case *string:
+		*v = string(data)
+		return value, nil
+	}
+
+	if err := json.Unmarshal(data, &value); err != nil {
+		return value, errors.Wrap(err, "unmarshal value")
+	}
+	return value, nil
+}
+
+func decodePair[T any](pair *api.KVPair) (*KVEntry[T], error) {
+	value, err := decodeValue[T](pair.Value)
+	if err != nil {
+		return nil, errors.Wrapf(err, "decode key(%s)", pair.Key)
+	}
+	return &KVEntry[T]{
+		Key:         pair.Key,
+		Value:       value,
+		ModifyIndex: pair.ModifyIndex,
+		Session:     pair.Session,
+	}, nil
+}
+
+// GetKV 读取 key 并将值解码为 T, key 不存在时返回 ErrKeyNotFound
+func GetKV[T any](ctx context.Context, c *Client, key string) (*KVEntry[T], error) {
+	pair, _, err := c.KV().Get(key, (&api.QueryOptions{}).WithContext(ctx))
+	if err != nil {
+		return nil, errors.Wrapf(err, "get key(%s)", key)
+	}
+	if pair == nil {
+		return nil, errors.Wrapf(ErrKeyNotFound, "key(%s)", key)
+	}
+	return decodePair[T](pair)
+}
+
+// PutKV 将 value 编码后写入 key
+func PutKV[T any](ctx context.Context, c *Client, key string, value T) error {
+	b, err := encodeValue(value)
+	if err != nil {
+		return err
+	}
+
+	_, err = c.KV().Put(&api.KVPair{Key: key, Value: b}, (&api.WriteOptions{}).WithContext(ctx))
+	return errors.Wrapf(err, "put key(%s)", key)
+}
+
+// CASKV 仅当 key 的 ModifyIndex 等于 modifyIndex 时写入, modifyIndex 为 0 表示仅当 key 不存在时写入
+//
+// key 已被修改时返回 ErrCASConflict。
+func CASKV[T any](ctx context.Context, c *Client, key string, value T, modifyIndex uint64) error {
+	b, err := encodeValue(value)
+	if err != nil {
+		return err
+	}
+
+	ok, _, err := c.KV().CAS(&api.KVPair{Key: key, Value: b, ModifyIndex: modifyIndex}, (&api.WriteOptions{}).WithContext(ctx))
+	if err != nil {
+		return errors.Wrapf(err, "cas key(%s)", key)
+	}
+	if !ok {
+		return errors.Wrapf(ErrCASConflict, "key(%s) index(%d)", key, modifyIndex)
+	}
+	return nil
+}
+
+// UpdateKV 读取 key 后通过 fn 计算新值并以 CAS 写入, 冲突时重新读取重试, key 不存在时 fn 的参数为零值
+func UpdateKV[T any](ctx context.Context, c *Client, key string, fn func(old T) (T, error)) error {
+	for {
+		var (
+			old   T
+			index uint64
+		)
+		entry, err := GetKV[T](ctx, c, key)
+		switch {
+		case err == nil:
+			old, index = entry.Value, entry.ModifyIndex
+		case errors.Is(err, ErrKeyNotFound):
+		default:
+			return err
+		}
+
+		value, err := fn(old)
+		if err != nil {
+			return err
+		}
+
+		err = CASKV(ctx, c, key, value, index)
+		if !errors.Is(err, ErrCASConflict) {
+			return err
+		}
+	}
+}
+
+// DeleteKV 删除 key, key 不存在时不返回错误
+func DeleteKV(ctx context.Context, c *Client, key string) error {
+	_, err := c.KV().Delete(key, (&api.WriteOptions{}).WithContext(ctx))
+	return errors.Wrapf(err, "delete key(%s)", key)
+}
+
+// ListKV 列出 prefix 下的所有 key 并解码, 任意值解码失败时返回错误
+func ListKV[T any](ctx context.Context, c *Client, prefix string) ([]*KVEntry[T], error) {
+	pairs, _, err := c.KV().List(prefix, (&api.QueryOptions{}).WithContext(ctx))
+	if err != nil {
+		return nil, errors.Wrapf(err, "list prefix(%s)", prefix)
+	}
+	return decodePairs[T](pairs)
+}
+
+func decodePairs[T any](pairs api.KVPairs) ([]*KVEntry[T], error) {
+	entries := make([]*KVEntry[T], 0, len(pairs))
+	for _, pair := range pairs {
+		entry, err := decodePair[T](pair)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
+
+// WatchKV 通过 blocking query 监听 prefix 下的 key, 首次及每次变更时发送 prefix 下的全部 key
+//
+// channel 只保留最新的一次结果, 请求或解码失败时退避重试并保留上一次的结果, ctx 结束后关闭 channel。
+func WatchKV[T any](ctx context.Context, c *Client, prefix string) <-chan []*KVEntry[T] {
+	ch := make(chan []*KVEntry[T], 1)
+	go watchKV(ctx, c, prefix, ch)
+	return ch
+}
+
+func watchKV[T any](ctx context.Context, c *Client, prefix string, ch chan []*KVEntry[T]) {
+	defer close(ch)
+
+	var (
+		index   uint64
+		backoff = kvWatchMinBackoff
+	)
+	for ctx.Err() == nil {
+		opts := (&api.QueryOptions{WaitIndex: index, WaitTime: defaultKVWatchWaitTime}).WithContext(ctx)
+		pairs, meta, err := c.KV().List(prefix, opts)
+		var entries []*KVEntry[T]
+		if err == nil {
+			entries, err = decodePairs[T](pairs)
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			innerlog.Logger.Errorf("Watch consul prefix(%s) failed, retry in %v. err: %v", prefix, backoff, err)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff = min(backoff*2, kvWatchMaxBackoff)
+			continue
+		}
+		backoff = kvWatchMinBackoff
+
+		if meta.LastIndex == index {
+			continue
+		}
+		// index 回退时(如 consul 重建)重新开始监听
+		if meta.LastIndex < index {
+			index = 0
+			continue
+		}
+		index = meta.LastIndex
+
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- entries
+	}
+}
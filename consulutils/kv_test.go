@@ -0,0 +1,70 @@
+package consulutils
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type kvConf struct {
+	Host string `json:"host"`
+	Port int    `json:"port"`
+}
+
+func TestEncodeValue(t *testing.T) {
+	b, err := encodeValue([]byte("raw"))
+	require.NoError(t, err)
+	assert.Equal(t, "raw", string(b))
+
+	// string 不经过 JSON 编码, 不会带引号
+	b, err = encodeValue("plain")
+	require.NoError(t, err)
+	assert.Equal(t, "plain", string(b))
+
+	b, err = encodeValue(kvConf{Host: "db", Port: 3306})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"host":"db","port":3306}`, string(b))
+
+	b, err = encodeValue(42)
+	require.NoError(t, err)
+	assert.Equal(t, "42", string(b))
+
+	_, err = encodeValue(make(chan int))
+	assert.ErrorContains(t, err, "marshal value")
+}
+
+func TestDecodeValue(t *testing.T) {
+	raw, err := decodeValue[[]byte]([]byte(`{"host":"db"}`))
+	require.NoError(t, err)
+	assert.Equal(t, `{"host":"db"}`, string(raw))
+
+	s, err := decodeValue[string]([]byte(`"quoted"`))
+	require.NoError(t, err)
+	assert.Equal(t, `"quoted"`, s)
+
+	conf, err := decodeValue[kvConf]([]byte(`{"host":"db","port":3306}`))
+	require.NoError(t, err)
+	assert.Equal(t, kvConf{Host: "db", Port: 3306}, conf)
+
+	ptr, err := decodeValue[*kvConf]([]byte(`{"host":"db"}`))
+	require.NoError(t, err)
+	assert.Equal(t, "db", ptr.Host)
+
+	_, err = decodeValue[kvConf]([]byte("plain"))
+	assert.ErrorContains(t, err, "unmarshal value")
+}
+
+func TestEncodeDecodeRoundTrip(t *testing.T) {
+	b, err := encodeValue(map[string]int{"a": 1})
+	require.NoError(t, err)
+	m, err := decodeValue[map[string]int](b)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"a": 1}, m)
+
+	b, err = encodeValue("line\n")
+	require.NoError(t, err)
+	s, err := decodeValue[string](b)
+	require.NoError(t, err)
+	assert.Equal(t, "line\n", s)
+}
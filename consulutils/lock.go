@@ -0,0 +1,251 @@
+// File:		lock.go
+// Created by:	Hoven
+// Created on:	2026-10-17
+//
+// This file is part of the Example Project.
+//
+// (c) 2024 Example Corp. All rights reserved.
+
+package consulutils
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/pkg/errors"
+)
+
+var (
+	// ErrLockLost 持有锁期间 session 失效或锁被删除
+	ErrLockLost = errors.New("consul lock lost")
+	// ErrLockNotAcquired 使用 WithLockTryOnce 时未能在等待时间内获取锁
+	ErrLockNotAcquired = errors.New("consul lock not acquired")
+
+	defaultLockSessionTTL = time.Second * 15
+)
+
+type lockOptions struct {
+	sessionTTL  time.Duration
+	lockDelay   time.Duration
+	value       []byte
+	sessionName string
+	tryOnce     bool
+	waitTime    time.Duration
+}
+
+type LockOption func(*lockOptions)
+
+// WithLockSessionTTL 设置 session 的 TTL, 默认 15s, session 在持有期间按 TTL/2 自动续期
+func WithLockSessionTTL(ttl time.Duration) LockOption {
+	return func(o *lockOptions) {
+		if ttl > 0 {
+			o.sessionTTL = ttl
+		}
+	}
+}
+
+// WithLockDelay 设置 session 失效后锁不可被再次获取的时间, 默认使用 consul 的 15s
+func WithLockDelay(delay time.Duration) LockOption {
+	return func(o *lockOptions) {
+		o.lockDelay = delay
+	}
+}
+
+// WithLockValue 设置持有锁时写入 key 的值, 通常为持有者的标识
+func WithLockValue(value []byte) LockOption {
+	return func(o *lockOptions) {
+		o.value = value
+	}
+}
+
+// WithLockSessionName 设置 session 的名称, 便于在 consul 中排查
+func WithLockSessionName(name string) LockOption {
+	return func(o *lockOptions) {
+		o.sessionName = name
+	}
+}
+
+// WithLockTryOnce 仅尝试获取一次, 最多等待 wait, 获取失败时返回 ErrLockNotAcquired
+func WithLockTryOnce(wait time.Duration) LockOption {
+	return func(o *lockOptions) {
+		o.tryOnce = true
+		o.waitTime = wait
+	}
+}
+
+func newLockOptions(opts []LockOption) *lockOptions {
+	o := &lockOptions{sessionTTL: defaultLockSessionTTL}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o
+}
+
+// acquire 将 ctx 转换为 consul 需要的 stopCh, 阻塞直到获取成功, ctx 结束或 try once 超时
+func acquire(ctx context.Context, tryOnce bool, fn func(stopCh <-chan struct{}) (<-chan struct{}, error)) (<-chan struct{}, error) {
+	stopCh := make(chan struct{})
+	stop := context.AfterFunc(ctx, func() {
+		close(stopCh)
+	})
+	defer stop()
+
+	lost, err := fn(stopCh)
+	if err != nil {
+		return nil, err
+	}
+	if lost == nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		if tryOnce {
+			return nil, ErrLockNotAcquired
+		}
+		return nil, errors.New("acquire stopped")
+	}
+	return lost, nil
+}
+
+// Lock 基于 consul session 的分布式互斥锁, 同一个 Lock 不可并发使用
+type Lock struct {
+	key  string
+	opts *lockOptions
+	lock *api.Lock
+}
+
+// NewLock 创建 key 上的分布式锁
+func (c *Client) NewLock(key string, opts ...LockOption) (*Lock, error) {
+	o := newLockOptions(opts)
+	lock, err := c.LockOpts(&api.LockOptions{
+		Key:              key,
+		Value:            o.value,
+		SessionName:      o.sessionName,
+		SessionTTL:       o.sessionTTL.String(),
+		MonitorRetries:   3,
+		LockDelay:        o.lockDelay,
+		LockTryOnce:      o.tryOnce,
+		LockWaitTime:     o.waitTime,
+		MonitorRetryTime: time.Second,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "create lock(%s)", key)
+	}
+	return &Lock{key: key, opts: o, lock: lock}, nil
+}
+
+// Lock 阻塞直到获取锁或 ctx 结束, 返回的 channel 在失去锁时关闭
+func (l *Lock) Lock(ctx context.Context) (<-chan struct{}, error) {
+	lost, err := acquire(ctx, l.opts.tryOnce, l.lock.Lock)
+	if err != nil {
+		return nil, errors.Wrapf(err, "acquire lock(%s)", l.key)
+	}
+	return lost, nil
+}
+
+// Unlock 释放锁并销毁 session, 未持有锁时不返回错误
+//
+// consul 在失去锁后仍会继续为 session 续期, 因此失去锁后也需要调用 Unlock。
+func (l *Lock) Unlock() error {
+	if err := l.lock.Unlock(); err != nil && !errors.Is(err, api.ErrLockNotHeld) {
+		return errors.Wrapf(err, "release lock(%s)", l.key)
+	}
+	// 其他持有者仍在使用该 key 时无需删除
+	if err := l.lock.Destroy(); err != nil && !errors.Is(err, api.ErrLockInUse) && !errors.Is(err, api.ErrLockHeld) {
+		return errors.Wrapf(err, "destroy lock(%s)", l.key)
+	}
+	return nil
+}
+
+// Semaphore 基于 consul session 的分布式信号量, 同一个 Semaphore 不可并发使用
+type Semaphore struct {
+	prefix string
+	opts   *lockOptions
+	sema   *api.Semaphore
+}
+
+// NewSemaphore 创建 prefix 下最多允许 limit 个持有者的信号量, 所有持有者的 limit 必须一致
+func (c *Client) NewSemaphore(prefix string, limit int, opts ...LockOption) (*Semaphore, error) {
+	o := newLockOptions(opts)
+	sema, err := c.SemaphoreOpts(&api.SemaphoreOptions{
+		Prefix:            prefix,
+		Limit:             limit,
+		Value:             o.value,
+		SessionName:       o.sessionName,
+		SessionTTL:        o.sessionTTL.String(),
+		MonitorRetries:    3,
+		MonitorRetryTime:  time.Second,
+		SemaphoreTryOnce:  o.tryOnce,
+		SemaphoreWaitTime: o.waitTime,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "create semaphore(%s)", prefix)
+	}
+	return &Semaphore{prefix: prefix, opts: o, sema: sema}, nil
+}
+
+// Acquire 阻塞直到获取一个名额或 ctx 结束, 返回的 channel 在失去名额时关闭
+func (s *Semaphore) Acquire(ctx context.Context) (<-chan struct{}, error) {
+	lost, err := acquire(ctx, s.opts.tryOnce, s.sema.Acquire)
+	if err != nil {
+		return nil, errors.Wrapf(err, "acquire semaphore(%s)", s.prefix)
+	}
+	return lost, nil
+}
+
+// Release 释放名额, 未持有时不返回错误
+func (s *Semaphore) Release() error {
+	if err := s.sema.Release(); err != nil && !errors.Is(err, api.ErrSemaphoreNotHeld) {
+		return errors.Wrapf(err, "release semaphore(%s)", s.prefix)
+	}
+	if err := s.sema.Destroy(); err != nil && !errors.Is(err, api.ErrSemaphoreInUse) && !errors.Is(err, api.ErrSemaphoreHeld) {
+		return errors.Wrapf(err, "destroy semaphore(%s)", s.prefix)
+	}
+	return nil
+}
+
+// DoWithLock 获取 key 上的锁后执行 fn, 失去锁时取消传入 fn 的 ctx(cause 为 ErrLockLost), fn 返回后释放锁
+func (c *Client) DoWithLock(ctx context.Context, key string, fn func(ctx context.Context) error, opts ...LockOption) error {
+	lock, err := c.NewLock(key, opts...)
+	if err != nil {
+		return err
+	}
+
+	lost, err := lock.Lock(ctx)
+	if err != nil {
+		return err
+	}
+	defer lock.Unlock()
+
+	return runHolding(ctx, lost, fn)
+}
+
+// DoWithSemaphore 获取 prefix 信号量的名额后执行 fn, 失去名额时取消传入 fn 的 ctx(cause 为 ErrLockLost)
+func (c *Client) DoWithSemaphore(ctx context.Context, prefix string, limit int, fn func(ctx context.Context) error, opts ...LockOption) error {
+	sema, err := c.NewSemaphore(prefix, limit, opts...)
+	if err != nil {
+		return err
+	}
+
+	lost, err := sema.Acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer sema.Release()
+
+	return runHolding(ctx, lost, fn)
+}
+
+func runHolding(ctx context.Context, lost <-chan struct{}, fn func(ctx context.Context) error) error {
+	ctx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	go func() {
+		select {
+		case <-lost:
+			cancel(ErrLockLost)
+		case <-ctx.Done():
+		}
+	}()
+
+	return fn(ctx)
+}
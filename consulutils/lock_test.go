@@ -0,0 +1,80 @@
+package consulutils
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func isClosed(ch <-chan struct{}) bool {
+	select {
+	case <-ch:
+		return true
+	default:
+		return false
+	}
+}
+
+func TestAcquire(t *testing.T) {
+	lost := make(chan struct{})
+	var stopCh <-chan struct{}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	got, err := acquire(ctx, false, func(ch <-chan struct{}) (<-chan struct{}, error) {
+		stopCh = ch
+		return lost, nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, (<-chan struct{})(lost), got)
+
+	// 获取成功后 ctx 结束不再关闭 stopCh
+	cancel()
+	time.Sleep(time.Millisecond * 10)
+	assert.False(t, isClosed(stopCh))
+}
+
+func TestAcquireCancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(time.Millisecond*10, cancel)
+
+	_, err := acquire(ctx, false, func(stopCh <-chan struct{}) (<-chan struct{}, error) {
+		select {
+		case <-stopCh:
+		case <-time.After(time.Second):
+			t.Error("stopCh not closed after ctx canceled")
+		}
+		return nil, nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+
+	// try once 时 ctx 的错误优先
+	ctx, cancel = context.WithCancel(context.Background())
+	cancel()
+	_, err = acquire(ctx, true, func(stopCh <-chan struct{}) (<-chan struct{}, error) {
+		<-stopCh
+		return nil, nil
+	})
+	assert.ErrorIs(t, err, context.Canceled)
+}
+
+func TestAcquireNotAcquired(t *testing.T) {
+	noLock := func(stopCh <-chan struct{}) (<-chan struct{}, error) {
+		return nil, nil
+	}
+
+	_, err := acquire(context.Background(), true, noLock)
+	assert.ErrorIs(t, err, ErrLockNotAcquired)
+
+	_, err = acquire(context.Background(), false, noLock)
+	assert.ErrorContains(t, err, "acquire stopped")
+
+	errBoom := errors.New("boom")
+	_, err = acquire(context.Background(), true, func(stopCh <-chan struct{}) (<-chan struct{}, error) {
+		return nil, errBoom
+	})
+	assert.ErrorIs(t, err, errBoom)
+}
@@ -0,0 +1,356 @@
+// File:		register.go
+// Created by:	Hoven
+// Created on:	2026-10-17
+//
+// This file is part of the Example Project.
+//
+// (c) 2024 Example Corp. All rights reserved.
+
+package consulutils
+
+import (
+	"fmt"
+	"maps"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/miebyte/goutils/buildinfo"
+	"github.com/miebyte/goutils/internal/innerlog"
+	"github.com/pkg/errors"
+	"gopkg.in/mgo.v2/bson"
+)
+
+type checkType int
+
+const (
+	checkTCP checkType = iota
+	checkHTTP
+	checkTTL
+)
+
+var (
+	defaultCheckInterval      = time.Second * 10
+	defaultDeregisterAfter    = time.Minute * 10
+	defaultReregisterInterval = time.Second * 30
+)
+
+type registerOptions struct {
+	tags    []string
+	meta    map[string]string
+	weights *api.AgentWeights
+
+	check              checkType
+	httpURL            string
+	tlsSkipVerify      bool
+	interval           time.Duration
+	timeout            time.Duration
+	ttl                time.Duration
+	deregisterAfter    time.Duration
+	reregisterInterval time.Duration
+}
+
+type RegisterOption func(*registerOptions)
+
+// WithRegisterTags 设置服务的 tag, 空 tag 会被忽略
+func WithRegisterTags(tags ...string) RegisterOption {
+	return func(o *registerOptions) {
+		for _, tag := range tags {
+			if tag != "" {
+				o.tags = append(o.tags, tag)
+			}
+		}
+	}
+}
+
+// WithRegisterMeta 设置服务的 meta, 默认包含 version 与 hostname
+func WithRegisterMeta(key, value string) RegisterOption {
+	return func(o *registerOptions) {
+		o.meta[key] = value
+	}
+}
+
+// WithRegisterWeights 设置服务在 passing 与 warning 状态下的权重
+func WithRegisterWeights(passing, warning int) RegisterOption {
+	return func(o *registerOptions) {
+		o.weights = &api.AgentWeights{Passing: passing, Warning: warning}
+	}
+}
+
+// WithTCPCheck 使用 TCP 健康检查, 默认行为
+func WithTCPCheck() RegisterOption {
+	return func(o *registerOptions) {
+		o.check = checkTCP
+	}
+}
+
+// WithHTTPCheck 使用 HTTP 健康检查, 返回 2xx 视为 passing, 429 视为 warning
+// url 以 / 开头时视为路径, 拼接在注册地址之后, 如 "/readyz"
+func WithHTTPCheck(url string) RegisterOption {
+	return func(o *registerOptions) {
+		o.check = checkHTTP
+		o.httpURL = url
+	}
+}
+
+// WithCheckTLSSkipVerify HTTP 健康检查不校验服务端证书
+func WithCheckTLSSkipVerify() RegisterOption {
+	return func(o *registerOptions) {
+		o.tlsSkipVerify = true
+	}
+}
+
+// WithTTLCheck 使用 TTL 健康检查, 由后台 goroutine 每 ttl/2 上报一次 passing
+func WithTTLCheck(ttl time.Duration) RegisterOption {
+	return func(o *registerOptions) {
+		if ttl > 0 {
+			o.check = checkTTL
+			o.ttl = ttl
+		}
+	}
+}
+
+// WithCheckInterval 设置 TCP/HTTP 健康检查的间隔与超时, 默认间隔 10s, timeout 为 0 时使用 consul 的默认值
+func WithCheckInterval(interval, timeout time.Duration) RegisterOption {
+	return func(o *registerOptions) {
+		if interval > 0 {
+			o.interval = interval
+		}
+		o.timeout = timeout
+	}
+}
+
+// WithDeregisterCriticalAfter 设置健康检查持续失败多久后由 consul 自动注销服务, 默认 10m
+func WithDeregisterCriticalAfter(d time.Duration) RegisterOption {
+	return func(o *registerOptions) {
+		o.deregisterAfter = d
+	}
+}
+
+// WithReregisterInterval 设置检查服务是否仍在 agent 中的间隔, 默认 30s, <= 0 时不检查
+// agent 重启等原因丢失服务时会自动重新注册
+func WithReregisterInterval(interval time.Duration) RegisterOption {
+	return func(o *registerOptions) {
+		o.reregisterInterval = interval
+	}
+}
+
+// RegisterOptionTags 返回 opts 中设置的 tag, 供不支持注册参数的注册方式使用
+func RegisterOptionTags(opts ...RegisterOption) []string {
+	o := &registerOptions{meta: make(map[string]string)}
+	for _, opt := range opts {
+		opt(o)
+	}
+	return o.tags
+}
+
+type registration struct {
+	RegisteredService
+
+	service            *api.AgentServiceRegistration
+	ttl                time.Duration
+	reregisterInterval time.Duration
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+func registerHostname() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		hostname = bson.NewObjectId().Hex()
+	}
+	return strings.ReplaceAll(hostname, ".", "-")
+}
+
+// RegisterServiceWithOptions 注册服务, 默认使用 10s 一次的 TCP 健康检查
+// 注册的服务在 Close 时注销
+func (c *Client) RegisterServiceWithOptions(serviceName string, address string, opts ...RegisterOption) error {
+	if !validServiceName(serviceName) {
+		return errors.New("Invalid service name")
+	}
+
+	// parse host and port from address
+	ip, err := net.ResolveTCPAddr("tcp", address)
+	if err != nil {
+		return err
+	}
+
+	hostname := registerHostname()
+	o := &registerOptions{
+		meta: map[string]string{
+			"version":  buildinfo.Version,
+			"hostname": hostname,
+		},
+		interval:           defaultCheckInterval,
+		deregisterAfter:    defaultDeregisterAfter,
+		reregisterInterval: defaultReregisterInterval,
+	}
+	for _, opt := range opts {
+		opt(o)
+	}
+
+	serviceID := fmt.Sprintf("%s-%d-%s", serviceName, ip.Port, hostname)
+	checkID := fmt.Sprintf("service:%s", serviceID)
+
+	check := &api.AgentServiceCheck{
+		CheckID: checkID,
+		Name:    serviceID,
+	}
+	if o.deregisterAfter > 0 {
+		check.DeregisterCriticalServiceAfter = o.deregisterAfter.String()
+	}
+	switch o.check {
+	case checkHTTP:
+		check.HTTP = o.httpURL
+		if strings.HasPrefix(o.httpURL, "/") {
+			check.HTTP = "http://" + address + o.httpURL
+		}
+		check.Method = http.MethodGet
+		check.TLSSkipVerify = o.tlsSkipVerify
+		check.Interval = o.interval.String()
+	case checkTTL:
+		check.TTL = o.ttl.String()
+	default:
+		check.TCP = address
+		check.Interval = o.interval.String()
+		check.Status = api.HealthPassing
+	}
+	if o.timeout > 0 && o.check != checkTTL {
+		check.Timeout = o.timeout.String()
+	}
+
+	r := &registration{
+		RegisteredService: RegisteredService{ServiceID: serviceID, CheckID: checkID},
+		service: &api.AgentServiceRegistration{
+			ID:      serviceID,
+			Name:    serviceName,
+			Port:    ip.Port,
+			Address: ip.IP.String(),
+			Tags:    o.tags,
+			Meta:    maps.Clone(o.meta),
+			Weights: o.weights,
+			Check:   check,
+		},
+		reregisterInterval: o.reregisterInterval,
+		stop:               make(chan struct{}),
+		done:               make(chan struct{}),
+	}
+	if o.check == checkTTL {
+		r.ttl = o.ttl
+	}
+
+	if err := c.Agent().ServiceRegister(r.service); err != nil {
+		return errors.Errorf("initial register service '%s' host to consul error: %s", serviceName, err.Error())
+	}
+	if r.ttl > 0 {
+		if err := c.Agent().UpdateTTL(checkID, "", api.HealthPassing); err != nil {
+			innerlog.Logger.Warnf("Update ttl check of service %v error: %v", serviceID, err)
+		}
+	}
+
+	go c.maintain(r)
+
+	c.mu.Lock()
+	c.services = append(c.services, r)
+	c.mu.Unlock()
+	return nil
+}
+
+// maintain 上报 TTL 心跳, 并在 agent 丢失服务时重新注册
+func (c *Client) maintain(r *registration) {
+	defer close(r.done)
+
+	var heartbeat, reregister <-chan time.Time
+	if r.ttl > 0 {
+		ticker := time.NewTicker(r.ttl / 2)
+		defer ticker.Stop()
+		heartbeat = ticker.C
+	}
+	if r.reregisterInterval > 0 {
+		ticker := time.NewTicker(r.reregisterInterval)
+		defer ticker.Stop()
+		reregister = ticker.C
+	}
+
+	for {
+		select {
+		case <-r.stop:
+			return
+		case <-heartbeat:
+			err := c.Agent().UpdateTTL(r.CheckID, "", api.HealthPassing)
+			if err == nil {
+				continue
+			}
+			innerlog.Logger.Warnf("Update ttl check of service %v error: %v", r.ServiceID, err)
+			if isNotFound(err) {
+				c.reregister(r)
+			}
+		case <-reregister:
+			_, _, err := c.Agent().Service(r.ServiceID, nil)
+			if err == nil {
+				continue
+			}
+			if !isNotFound(err) {
+				innerlog.Logger.Warnf("Query service %v from consul agent error: %v", r.ServiceID, err)
+				continue
+			}
+			innerlog.Logger.Warnf("Service %v not found in consul agent, re-registering", r.ServiceID)
+			c.reregister(r)
+		}
+	}
+}
+
+func (c *Client) reregister(r *registration) {
+	if err := c.Agent().ServiceRegister(r.service); err != nil {
+		innerlog.Logger.Errorf("Re-register service %v error: %v", r.ServiceID, err)
+		return
+	}
+	if r.ttl > 0 {
+		if err := c.Agent().UpdateTTL(r.CheckID, "", api.HealthPassing); err != nil {
+			innerlog.Logger.Warnf("Update ttl check of service %v error: %v", r.ServiceID, err)
+		}
+	}
+	innerlog.Logger.Infof("Re-registered service: %v success", r.ServiceID)
+}
+
+// isNotFound agent 中不存在对应的服务或检查, 旧版本 agent 更新不存在的 TTL 检查时返回 500
+func isNotFound(err error) bool {
+	var se api.StatusError
+	if errors.As(err, &se) {
+		return se.Code == http.StatusNotFound || strings.Contains(se.Body, "does not have associated TTL")
+	}
+	return strings.Contains(err.Error(), "does not have associated TTL")
+}
+
+func (c *Client) deregisterServiceAndCheck(serviceID, checkID string) (reterr error) {
+	if err := c.Agent().CheckDeregister(checkID); err != nil {
+		reterr = errors.Wrap(err, "Deregister check")
+	}
+
+	if err := c.Agent().ServiceDeregister(serviceID); err != nil {
+		reterr = errors.Wrap(err, "Deregister service")
+	}
+	return
+}
+
+func (c *Client) Close() {
+	c.mu.Lock()
+	services := c.services
+	c.services = nil
+	c.mu.Unlock()
+
+	for _, r := range services {
+		close(r.stop)
+		<-r.done
+
+		if err := c.deregisterServiceAndCheck(r.ServiceID, r.CheckID); err != nil {
+			innerlog.Logger.Errorf("Deregister service %v error: %v", r.ServiceID, err)
+		} else {
+			innerlog.Logger.Infof("Deregistered service: %v success", r.ServiceID)
+		}
+	}
+}
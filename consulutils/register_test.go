@@ -0,0 +1,238 @@
+package consulutils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/miebyte/goutils/buildinfo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeAgent 模拟 consul agent 的服务注册相关接口
+type fakeAgent struct {
+	mu         sync.Mutex
+	services   map[string]*api.AgentServiceRegistration
+	registered []*api.AgentServiceRegistration
+	ttlUpdates int
+}
+
+func newFakeAgent(t *testing.T) (*fakeAgent, *Client) {
+	t.Helper()
+
+	fa := &fakeAgent{services: make(map[string]*api.AgentServiceRegistration)}
+	mux := http.NewServeMux()
+	mux.HandleFunc("PUT /v1/agent/service/register", func(w http.ResponseWriter, r *http.Request) {
+		reg := new(api.AgentServiceRegistration)
+		if err := json.NewDecoder(r.Body).Decode(reg); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		fa.mu.Lock()
+		fa.services[reg.ID] = reg
+		fa.registered = append(fa.registered, reg)
+		fa.mu.Unlock()
+	})
+	mux.HandleFunc("GET /v1/agent/service/{id}", func(w http.ResponseWriter, r *http.Request) {
+		fa.mu.Lock()
+		reg, ok := fa.services[r.PathValue("id")]
+		fa.mu.Unlock()
+		if !ok {
+			http.Error(w, "unknown service ID", http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(&api.AgentService{ID: reg.ID, Service: reg.Name})
+	})
+	mux.HandleFunc("PUT /v1/agent/check/update/{id}", func(w http.ResponseWriter, r *http.Request) {
+		fa.mu.Lock()
+		defer fa.mu.Unlock()
+		fa.ttlUpdates++
+		if _, ok := fa.services[strings.TrimPrefix(r.PathValue("id"), "service:")]; !ok {
+			http.Error(w, "unknown check ID", http.StatusNotFound)
+		}
+	})
+	mux.HandleFunc("PUT /v1/agent/service/deregister/{id}", func(w http.ResponseWriter, r *http.Request) {
+		fa.drop(r.PathValue("id"))
+	})
+	mux.HandleFunc("PUT /v1/agent/check/deregister/{id}", func(w http.ResponseWriter, r *http.Request) {})
+
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return fa, newConsulClient(strings.TrimPrefix(srv.URL, "http://"))
+}
+
+// drop 模拟 agent 重启等原因丢失服务
+func (fa *fakeAgent) drop(serviceID string) {
+	fa.mu.Lock()
+	delete(fa.services, serviceID)
+	fa.mu.Unlock()
+}
+
+func (fa *fakeAgent) registerCount() int {
+	fa.mu.Lock()
+	defer fa.mu.Unlock()
+	return len(fa.registered)
+}
+
+func (fa *fakeAgent) lastRegistered() *api.AgentServiceRegistration {
+	fa.mu.Lock()
+	defer fa.mu.Unlock()
+	return fa.registered[len(fa.registered)-1]
+}
+
+func (fa *fakeAgent) serviceCount() int {
+	fa.mu.Lock()
+	defer fa.mu.Unlock()
+	return len(fa.services)
+}
+
+func TestRegisterServiceCheck(t *testing.T) {
+	tests := []struct {
+		name  string
+		opts  []RegisterOption
+		check api.AgentServiceCheck
+	}{
+		{
+			name: "tcp",
+			check: api.AgentServiceCheck{
+				TCP:                            "127.0.0.1:8080",
+				Interval:                       "10s",
+				Status:                         api.HealthPassing,
+				DeregisterCriticalServiceAfter: "10m0s",
+			},
+		},
+		{
+			name: "http path",
+			opts: []RegisterOption{
+				WithHTTPCheck("/readyz"),
+				WithCheckInterval(5*time.Second, time.Second),
+				WithDeregisterCriticalAfter(0),
+			},
+			check: api.AgentServiceCheck{
+				HTTP:     "http://127.0.0.1:8080/readyz",
+				Method:   http.MethodGet,
+				Interval: "5s",
+				Timeout:  "1s",
+			},
+		},
+		{
+			name: "http url",
+			opts: []RegisterOption{
+				WithHTTPCheck("https://127.0.0.1:9090/readyz"),
+				WithCheckTLSSkipVerify(),
+			},
+			check: api.AgentServiceCheck{
+				HTTP:                           "https://127.0.0.1:9090/readyz",
+				Method:                         http.MethodGet,
+				TLSSkipVerify:                  true,
+				Interval:                       "10s",
+				DeregisterCriticalServiceAfter: "10m0s",
+			},
+		},
+		{
+			name: "ttl",
+			opts: []RegisterOption{
+				WithTTLCheck(time.Minute),
+				WithCheckInterval(5*time.Second, time.Second),
+			},
+			check: api.AgentServiceCheck{
+				TTL:                            "1m0s",
+				DeregisterCriticalServiceAfter: "10m0s",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fa, client := newFakeAgent(t)
+			opts := append([]RegisterOption{WithReregisterInterval(0)}, tt.opts...)
+			require.NoError(t, client.RegisterServiceWithOptions("demo", "127.0.0.1:8080", opts...))
+
+			reg := fa.lastRegistered()
+			require.NotNil(t, reg.Check)
+			check := *reg.Check
+			assert.Equal(t, "service:"+reg.ID, check.CheckID)
+			assert.Equal(t, reg.ID, check.Name)
+			check.CheckID, check.Name = "", ""
+			assert.Equal(t, tt.check, check)
+
+			client.Close()
+			assert.Zero(t, fa.serviceCount())
+		})
+	}
+}
+
+func TestRegisterServiceMapping(t *testing.T) {
+	fa, client := newFakeAgent(t)
+	require.NoError(t, client.RegisterServiceWithOptions("demo", "127.0.0.1:8080",
+		WithRegisterTags("v1", "", "canary"),
+		WithRegisterMeta("zone", "a"),
+		WithRegisterWeights(10, 1),
+		WithReregisterInterval(0),
+	))
+	defer client.Close()
+
+	hostname := registerHostname()
+	reg := fa.lastRegistered()
+	assert.Equal(t, "demo-8080-"+hostname, reg.ID)
+	assert.Equal(t, "demo", reg.Name)
+	assert.Equal(t, "127.0.0.1", reg.Address)
+	assert.Equal(t, 8080, reg.Port)
+	assert.Equal(t, []string{"v1", "canary"}, reg.Tags)
+	assert.Equal(t, map[string]string{
+		"version":  buildinfo.Version,
+		"hostname": hostname,
+		"zone":     "a",
+	}, reg.Meta)
+	assert.Equal(t, &api.AgentWeights{Passing: 10, Warning: 1}, reg.Weights)
+
+	assert.Error(t, client.RegisterServiceWithOptions("demo_svc", "127.0.0.1:8080"))
+	assert.Error(t, client.RegisterServiceWithOptions("demo", "127.0.0.1"))
+	assert.Equal(t, 1, fa.registerCount())
+}
+
+func TestReregisterNotFound(t *testing.T) {
+	fa, client := newFakeAgent(t)
+	require.NoError(t, client.RegisterServiceWithOptions("demo", "127.0.0.1:8080",
+		WithReregisterInterval(20*time.Millisecond),
+	))
+	defer client.Close()
+
+	reg := fa.lastRegistered()
+	time.Sleep(60 * time.Millisecond)
+	assert.Equal(t, 1, fa.registerCount())
+
+	fa.drop(reg.ID)
+	assert.Eventually(t, func() bool {
+		return fa.serviceCount() == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.Equal(t, 2, fa.registerCount())
+	assert.Equal(t, reg, fa.lastRegistered())
+}
+
+func TestReregisterTTLNotFound(t *testing.T) {
+	fa, client := newFakeAgent(t)
+	require.NoError(t, client.RegisterServiceWithOptions("demo", "127.0.0.1:8080",
+		WithTTLCheck(40*time.Millisecond),
+		WithReregisterInterval(0),
+	))
+	defer client.Close()
+
+	// 注册后立即上报一次 passing
+	fa.mu.Lock()
+	assert.Equal(t, 1, fa.ttlUpdates)
+	fa.mu.Unlock()
+
+	// 心跳返回 404 时重新注册
+	fa.drop(fa.lastRegistered().ID)
+	assert.Eventually(t, func() bool {
+		return fa.serviceCount() == 1
+	}, time.Second, 10*time.Millisecond)
+	assert.GreaterOrEqual(t, fa.registerCount(), 2)
+}
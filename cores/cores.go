@@ -10,13 +10,15 @@ package cores
 
 import (
 	"context"
-	"fmt"
+	"crypto/tls"
 	"net"
 	"net/http"
-	"strconv"
+	"os"
+	"sync"
+	"sync/atomic"
 	"time"
 
-	"github.com/miebyte/goutils/internal/innerlog"
+	"github.com/miebyte/goutils/consulutils"
 	"github.com/miebyte/goutils/internal/share"
 	"github.com/miebyte/goutils/logging"
 	"github.com/pkg/errors"
@@ -31,18 +33,31 @@ type mountFn struct {
 	name    string
 	maxWait time.Duration
 	fn      func(ctx context.Context) error
+	// lifecycle 为 true 时不计入 worker 的退出等待(如 GracefulKill 自身)
+	lifecycle bool
+	status    *workerStatus
 }
 
 type CoresService struct {
-	ctx    context.Context
-	cancel func()
+	ctx       context.Context
+	cancel    func()
+	parentCtx context.Context
 
 	serviceName  string
 	tags         []string
 	needRegister bool
+	registerOpts []consulutils.RegisterOption
+	// registerReadyz 注册时使用 HTTP 检查 /readyz 代替默认的 TCP 检查
+	registerReadyz bool
 
 	listenAddr string
 	listener   net.Listener
+	listeners  []*namedListener
+	servers    []*mountedServer
+
+	gracefulRestart     bool
+	restartReadyTimeout time.Duration
+	handedOff           atomic.Bool
 
 	httpMux          *http.ServeMux
 	httpServerConfig *HttpServerConfig
@@ -50,13 +65,51 @@ type CoresService struct {
 	httpHandler      http.Handler
 	httpCors         bool
 	httpServer       *http.Server
-
-	workers     []Worker
-	mountFns    []mountFn
-	waitAllDone bool
+	httpTimeouts     map[string]time.Duration
+	httpTimeout      time.Duration
+	httpTimeoutCode  int
+	httpMaxBodySize  int64
+	httpLimiter      *concurrencyLimiter
+	adminHttpMux     *http.ServeMux
+	grpc             *grpcService
+	adminServer      *http.Server
+
+	workers        []Worker
+	mountFns       []mountFn
+	mountWg        sync.WaitGroup
+	waitAllDone    bool
+	workerStatuses []*workerStatus
+	startedAt      time.Time
+
+	hooks       []*LifecycleHook
+	sortedHooks []*LifecycleHook
+	stopOnce    sync.Once
+	started     chan struct{}
+	done        chan struct{}
+
+	signals        []os.Signal
+	disableSignals bool
+	shutdownMu     sync.Mutex
+	shutdownReason string
+	shutdownAt     time.Time
+	hookResults    []HookShutdown
+	report         *ShutdownReport
+
+	tlsConfig        *tls.Config
+	tlsCertFile      string
+	tlsKeyFile       string
+	tlsClientCAFiles []string
+	certReloader     *certReloader
 
 	usePprof      bool
 	usePrometheus bool
+	useDebug      bool
+	debugAuth     DebugAuthFunc
+
+	livenessChecks      []*healthCheck
+	readinessChecks     []*healthCheck
+	readinessDrainDelay time.Duration
+	shuttingDown        atomic.Bool
 }
 
 type ServiceOption func(*CoresService)
@@ -80,15 +133,14 @@ func WithRegisterService() ServiceOption {
 }
 
 func NewCores(opts ...ServiceOption) *CoresService {
-	ctx, cancel := context.WithCancel(context.TODO())
-
 	cs := &CoresService{
-		ctx:              ctx,
-		cancel:           cancel,
+		parentCtx:        context.TODO(),
 		httpMux:          http.NewServeMux(),
 		httpServerConfig: defaultHttpServerConfig,
 		httpPatterns:     make([]string, 0),
 		mountFns:         make([]mountFn, 0),
+		started:          make(chan struct{}),
+		done:             make(chan struct{}),
 	}
 	cs.httpHandler = cs.httpMux
 
@@ -96,22 +148,47 @@ func NewCores(opts ...ServiceOption) *CoresService {
 		opt(cs)
 	}
 
+	// 父 context 的取消由 gracefulKill 转换为优雅退出, 而不是直接取消所有 worker
+	cs.ctx, cs.cancel = context.WithCancel(context.WithoutCancel(cs.parentCtx))
+
 	return cs
 }
 
-func (c *CoresService) serve() error {
+func (c *CoresService) serve() (err error) {
+	defer func() {
+		c.finishShutdownReport(err)
+		close(c.done)
+	}()
+
 	c.injectServiceName()
 
+	if err := c.openListeners(); err != nil {
+		return err
+	}
+
 	c.mountFns = []mountFn{c.gracefulKill()}
 	if c.needRegister {
 		c.mountFns = append(c.mountFns, c.registerService())
 	}
 
-	if c.listener == nil {
-		return c.startServer()
+	if c.listener != nil {
+		c.mountFns = append(c.mountFns, c.listenHttp("HttpListener", c.listener, func() *http.Server { return c.httpServer }))
 	}
 
-	c.mountFns = append(c.mountFns, c.listenHttp(c.listener))
+	if nl := c.getListener(AdminListener); nl != nil {
+		c.adminHttpMux = http.NewServeMux()
+		c.mountFns = append(c.mountFns, c.listenHttp("AdminHttpListener", nl.listener, func() *http.Server { return c.adminServer }))
+	}
+
+	if err := c.setupGrpc(); err != nil {
+		c.closeListeners()
+		return errors.Wrap(err, "setupGrpc")
+	}
+
+	if err := c.mountServers(); err != nil {
+		c.closeListeners()
+		return err
+	}
 
 	return c.startServer()
 }
@@ -135,19 +212,75 @@ func (c *CoresService) startServer() error {
 	c.wrapWorker()
 	c.setupPprof()
 	c.setupPrometheus()
+	c.setupDebug()
+	if err := c.setupHttpServers(); err != nil {
+		c.closeListeners()
+		return errors.Wrap(err, "setupHttpServers")
+	}
+	c.mountCertReloader()
+
+	hooks, err := c.buildHooks()
+	if err != nil {
+		c.closeListeners()
+		return errors.Wrap(err, "buildHooks")
+	}
+	c.sortedHooks = hooks
+
+	if err := c.startHooks(); err != nil {
+		c.stopHooks()
+		c.closeListeners()
+		return err
+	}
 
 	c.welcome()
-	return c.runMountFn()
+	close(c.started)
+	notifyRestartReady()
+	err = c.runMountFn()
+	if err != nil {
+		c.setShutdownReason(err.Error())
+	}
+	c.stopHooks()
+	return err
 }
 
 func (c *CoresService) runMountFn() error {
 	grp, ctx := errgroup.WithContext(c.ctx)
 
+	// 状态列表需在任何 mountFn 启动前构建完成, 避免与调试接口并发读写
+	for i := range c.mountFns {
+		mf := &c.mountFns[i]
+		if mf.lifecycle {
+			continue
+		}
+		if mf.status == nil {
+			mf.status = newWorkerStatus(mf.name)
+		}
+		c.workerStatuses = append(c.workerStatuses, mf.status)
+	}
+	c.startedAt = time.Now()
+
 	for _, mount := range c.mountFns {
 		mf := mount
+		if !mf.lifecycle {
+			c.mountWg.Add(1)
+		}
 		grp.Go(func() (err error) {
+			if !mf.lifecycle {
+				defer c.mountWg.Done()
+			}
+
 			cctx := logging.With(ctx, "Worker", mf.name)
-			err = c.waitContext(cctx, mf.maxWait, mf.fn)
+			if mf.lifecycle {
+				err = mf.fn(cctx)
+			} else {
+				mf.status.setRunning()
+				err = c.waitContext(cctx, mf.maxWait, mf.fn)
+				mf.status.setStopped(err)
+				// 强制关闭只记录在退出报告中, 不作为服务的退出错误
+				if errors.Is(err, ErrForceClosed) {
+					err = nil
+				}
+			}
 			if err != nil {
 				return errors.Wrap(err, "waitContext")
 			}
@@ -163,27 +296,26 @@ func Run(srv *CoresService) error {
 }
 
 func Start[T listenEntry](srv *CoresService, addr T) error {
-	var address string
-	switch v := any(addr).(type) {
-	case int:
-		address = ":" + strconv.Itoa(v)
-	case string:
-		address = v
-	default:
-		innerlog.Logger.Errorf("unsupport address type: %T", v)
-	}
+	srv.addListener(DefaultListener, toAddress(addr))
+	return srv.serve()
+}
 
-	listener, err := net.Listen("tcp", address)
-	if err != nil {
-		return errors.Wrap(err, "failed to start listener")
-	}
-	srv.listener = listener
-	srv.listenAddr = listener.Addr().String()
+// Serve 使用已创建好的监听启动服务, 如测试中监听 127.0.0.1:0 的随机端口
+func Serve(srv *CoresService, lst net.Listener) error {
+	srv.addListener(DefaultListener, lst.Addr().String())
+	srv.getListener(DefaultListener).setListener(lst)
+	return srv.serve()
+}
 
-	lstAddr, ok := listener.Addr().(*net.TCPAddr)
-	if ok && lstAddr.IP.IsUnspecified() {
-		srv.listenAddr = fmt.Sprintf("127.0.0.1:%v", lstAddr.Port)
-	}
+// Started 返回在所有启动 hook 执行完毕, worker 开始运行时关闭的 channel
+func (c *CoresService) Started() <-chan struct{} {
+	return c.started
+}
 
-	return srv.serve()
+// ListenerAddr 返回命名监听的实际地址, 未指定 host 时为 127.0.0.1, 需在 Started 关闭后调用
+func (c *CoresService) ListenerAddr(name string) string {
+	if nl := c.getListener(name); nl != nil {
+		return nl.localAddr
+	}
+	return ""
 }
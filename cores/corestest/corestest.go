@@ -0,0 +1,252 @@
+// File:		corestest.go
+// Created by:	Hoven
+// Created on:	2026-10-17
+//
+// This file is part of the Example Project.
+//
+// (c) 2024 Example Corp. All rights reserved.
+
+// Package corestest 提供在测试进程内运行 CoresService 的工具
+//
+// 服务监听 127.0.0.1 的随机端口, 启动后等待 /readyz 就绪, 并捕获日志输出与 worker 错误,
+// 测试结束时自动优雅退出。日志捕获会替换全局日志输出, 因此不要在并行测试中同时使用多个 Service。
+package corestest
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miebyte/goutils/cores"
+	"github.com/miebyte/goutils/internal/innerlog"
+	"github.com/miebyte/goutils/logging"
+)
+
+var (
+	// ReadyTimeout 等待服务就绪的最长时间
+	ReadyTimeout = time.Second * 10
+	// ShutdownTimeout 测试结束时等待服务退出的最长时间
+	ShutdownTimeout = time.Second * 10
+
+	readyPollInterval = time.Millisecond * 20
+)
+
+// Service 运行中的测试服务
+type Service struct {
+	*cores.CoresService
+
+	// URL 业务 http 服务的地址, 如 http://127.0.0.1:54321
+	URL string
+	// AdminURL 管理端点(健康检查, pprof, metrics 等)所在的地址, 未配置管理监听时与 URL 相同
+	AdminURL string
+
+	t    testing.TB
+	logs *LogBuffer
+
+	done chan struct{}
+	err  error
+}
+
+// Start 在随机端口上启动服务并等待就绪, 测试结束时自动退出
+// 服务默认不处理退出信号, 避免拦截 go test 进程的 Ctrl-C, 如需测试信号可在 opts 中传入 cores.WithSignals
+// 启动失败或超时未就绪时测试直接失败
+func Start(t testing.TB, opts ...cores.ServiceOption) *Service {
+	t.Helper()
+
+	lst, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("corestest: listen failed: %v", err)
+	}
+
+	s := &Service{
+		CoresService: cores.NewCores(append([]cores.ServiceOption{cores.WithoutSignals()}, opts...)...),
+		t:            t,
+		logs:         captureLogs(t),
+		done:         make(chan struct{}),
+	}
+
+	go func() {
+		defer close(s.done)
+		s.err = cores.Serve(s.CoresService, lst)
+	}()
+	t.Cleanup(s.cleanup)
+
+	select {
+	case <-s.Started():
+	case <-s.done:
+		t.Fatalf("corestest: service exited before started: %v", s.err)
+	case <-time.After(ReadyTimeout):
+		t.Fatalf("corestest: service not started within %v", ReadyTimeout)
+	}
+
+	s.URL = "http://" + s.ListenerAddr(cores.DefaultListener)
+	s.AdminURL = s.URL
+	if addr := s.ListenerAddr(cores.AdminListener); addr != "" {
+		s.AdminURL = "http://" + addr
+	}
+
+	if err := s.WaitReady(ReadyTimeout); err != nil {
+		t.Fatalf("corestest: %v", err)
+	}
+	return s
+}
+
+// WaitReady 轮询 /readyz 直到返回 200
+func (s *Service) WaitReady(timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	var lastErr error
+	for {
+		lastErr = s.checkReady(ctx)
+		if lastErr == nil {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("service not ready within %v: %w", timeout, lastErr)
+		case <-s.done:
+			return fmt.Errorf("service exited before ready: %v", s.err)
+		case <-time.After(readyPollInterval):
+		}
+	}
+}
+
+func (s *Service) checkReady(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.AdminURL+"/readyz", nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("readyz returned %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	return nil
+}
+
+// Shutdown 触发优雅退出并等待服务退出, 返回服务运行期间产生的错误
+func (s *Service) Shutdown(ctx context.Context) error {
+	if err := s.CoresService.Shutdown(ctx); err != nil {
+		return err
+	}
+
+	select {
+	case <-s.done:
+		return s.err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Wait 等待服务退出(如 worker 返回错误), 返回服务运行期间产生的错误
+func (s *Service) Wait() error {
+	<-s.done
+	return s.err
+}
+
+// Done 返回服务退出后关闭的 channel
+func (s *Service) Done() <-chan struct{} {
+	return s.done
+}
+
+// Err 返回服务退出时的错误, 服务仍在运行时返回 nil
+func (s *Service) Err() error {
+	select {
+	case <-s.done:
+		return s.err
+	default:
+		return nil
+	}
+}
+
+// WorkerErrors 返回所有记录了错误的 worker 及其最近一次错误
+func (s *Service) WorkerErrors() map[string]string {
+	errs := make(map[string]string)
+	for _, status := range s.WorkerStatuses() {
+		if status.LastError != "" {
+			errs[status.Name] = status.LastError
+		}
+	}
+	return errs
+}
+
+// Logs 返回服务启动以来捕获的日志
+func (s *Service) Logs() string {
+	return s.logs.String()
+}
+
+// LogBuffer 返回日志缓冲区, 可用于清空或按需断言
+func (s *Service) LogBuffer() *LogBuffer {
+	return s.logs
+}
+
+func (s *Service) cleanup() {
+	ctx, cancel := context.WithTimeout(context.Background(), ShutdownTimeout)
+	defer cancel()
+
+	select {
+	case <-s.done:
+	default:
+		if err := s.Shutdown(ctx); err != nil {
+			s.t.Errorf("corestest: shutdown failed: %v", err)
+		}
+	}
+
+	if s.t.Failed() {
+		s.t.Logf("corestest: captured logs:\n%s", s.Logs())
+	}
+}
+
+// LogBuffer 并发安全的日志缓冲区
+type LogBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *LogBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *LogBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func (b *LogBuffer) Reset() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.buf.Reset()
+}
+
+// captureLogs 将 logging 与 goutils 内部日志重定向到缓冲区, 测试结束时恢复
+func captureLogs(t testing.TB) *LogBuffer {
+	buf := new(LogBuffer)
+
+	prevOut := logging.GetLogger().Out
+	prevInnerOut := innerlog.Logger.Out
+	logging.SetOutput(buf)
+	innerlog.Logger.SetOutput(buf)
+
+	t.Cleanup(func() {
+		logging.SetOutput(prevOut)
+		innerlog.Logger.SetOutput(prevInnerOut)
+	})
+	return buf
+}
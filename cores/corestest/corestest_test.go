@@ -0,0 +1,101 @@
+package corestest
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/miebyte/goutils/cores"
+	"github.com/miebyte/goutils/logging"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStart(t *testing.T) {
+	stopped := make(chan struct{})
+	s := Start(t,
+		cores.WithHttpHandler("/hello", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			logging.Infoc(r.Context(), "hello handler called")
+			w.Write([]byte("world"))
+		})),
+		cores.WithNameWorker("idle", func(ctx context.Context) error {
+			<-ctx.Done()
+			close(stopped)
+			return nil
+		}),
+	)
+
+	resp, err := http.Get(s.URL + "/hello/")
+	assert.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, "world", string(body))
+	assert.Contains(t, s.Logs(), "hello handler called")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	assert.NoError(t, s.Shutdown(ctx))
+
+	select {
+	case <-stopped:
+	default:
+		t.Fatal("worker not stopped")
+	}
+}
+
+func TestWorkerError(t *testing.T) {
+	s := Start(t, cores.WithNameWorker("broken", func(ctx context.Context) error {
+		time.Sleep(100 * time.Millisecond)
+		return errors.New("boom")
+	}))
+
+	err := s.Wait()
+	assert.ErrorContains(t, err, "boom")
+	assert.Contains(t, s.WorkerErrors()["broken"], "boom")
+	assert.Contains(t, s.Logs(), "boom")
+}
+
+func TestShutdownReport(t *testing.T) {
+	s := Start(t,
+		cores.WithNameWorker("clean", func(ctx context.Context) error {
+			<-ctx.Done()
+			return nil
+		}),
+		cores.WithNameWorker("stuck", func(ctx context.Context) error {
+			<-ctx.Done()
+			time.Sleep(3 * time.Second)
+			return nil
+		}, 100*time.Millisecond),
+	)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	assert.NoError(t, s.Shutdown(ctx))
+
+	report := s.ShutdownReport()
+	assert.NotNil(t, report)
+	assert.Equal(t, "shutdown", report.Reason)
+	assert.False(t, report.Clean())
+	assert.Equal(t, []string{"stuck"}, report.ForceClosed())
+	for _, w := range report.Workers {
+		if w.Name == "clean" {
+			assert.Equal(t, cores.WorkerExitClean, w.Result)
+		}
+	}
+}
+
+func TestParentContext(t *testing.T) {
+	parent, cancel := context.WithCancel(context.Background())
+	s := Start(t, cores.WithContext(parent), cores.WithoutSignals())
+
+	cancel()
+	select {
+	case <-s.CoresService.Done():
+	case <-time.After(5 * time.Second):
+		t.Fatal("service not stopped after parent context canceled")
+	}
+	assert.NoError(t, s.Err())
+	assert.Equal(t, "context: context canceled", s.ShutdownReport().Reason)
+}
@@ -0,0 +1,283 @@
+// File:		cron.go
+// Created by:	Hoven
+// Created on:	2026-10-17
+//
+// This file is part of the Example Project.
+//
+// (c) 2024 Example Corp. All rights reserved.
+
+package cores
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/miebyte/goutils/internal/innerlog"
+	"github.com/miebyte/goutils/logging"
+	"github.com/miebyte/goutils/redisutils"
+	"github.com/pkg/errors"
+)
+
+// CronOverlapPolicy 上一次任务尚未结束时新一轮触发的处理策略
+type CronOverlapPolicy int
+
+const (
+	// CronOverlapSkip 跳过本次触发
+	CronOverlapSkip CronOverlapPolicy = iota
+	// CronOverlapQueue 排队, 等待上一次任务结束后再执行
+	CronOverlapQueue
+	// CronOverlapConcurrent 并发执行
+	CronOverlapConcurrent
+)
+
+const (
+	cronQueueSize = 16
+)
+
+type cronWorker struct {
+	*base
+
+	spec     string
+	schedule CronSchedule
+	parseErr error
+	overlap  CronOverlapPolicy
+	location *time.Location
+
+	locker  cronLocker
+	lockKey string
+	lockTTL time.Duration
+	ttlWarn sync.Once
+
+	running atomic.Int32
+}
+
+type CronOption func(*cronWorker)
+
+// cronLocker 多实例间互斥执行使用的锁, *redisutils.RedisClient 实现了该接口
+type cronLocker interface {
+	TryLock(ctx context.Context, key string, expiration time.Duration) error
+}
+
+// WithCronName 设置任务名称, 默认使用函数名
+func WithCronName(name string) CronOption {
+	return func(w *cronWorker) {
+		w.name = name
+	}
+}
+
+// WithCronOverlap 设置任务重叠策略, 默认 CronOverlapSkip
+func WithCronOverlap(policy CronOverlapPolicy) CronOption {
+	return func(w *cronWorker) {
+		w.overlap = policy
+	}
+}
+
+// WithCronLocation 设置计算触发时间所用的时区, 默认 time.Local
+func WithCronLocation(loc *time.Location) CronOption {
+	return func(w *cronWorker) {
+		if loc != nil {
+			w.location = loc
+		}
+	}
+}
+
+// WithCronMaxWait 设置退出时等待任务结束的最长时间
+func WithCronMaxWait(maxWait time.Duration) CronOption {
+	return func(w *cronWorker) {
+		w.maxWait = maxWait
+	}
+}
+
+// WithCronRedisLock 借助 redis 锁保证多实例部署时每次触发只有一个实例执行
+// key 为空时使用 "cores:cron:<name>", ttl 为 0 时取两次触发间隔的一半。
+// 锁不会主动释放, 以避免各实例时钟偏差导致同一轮触发被重复执行。
+// ttl 需小于两次触发的间隔, 否则下一次触发时锁仍未过期, 所有实例都会跳过该轮,
+// ttl 不小于间隔时按间隔的一半处理。
+func WithCronRedisLock(client *redisutils.RedisClient, key string, ttl time.Duration) CronOption {
+	return func(w *cronWorker) {
+		if client != nil {
+			w.locker = client
+		}
+		w.lockKey = key
+		w.lockTTL = ttl
+	}
+}
+
+// WithCronWorker 注册定时任务, spec 格式见 ParseCronSpec
+func WithCronWorker(spec string, fn WorkerFunc, opts ...CronOption) ServiceOption {
+	w := &cronWorker{
+		base: &base{
+			name:    logging.GetFuncName(fn),
+			maxWait: defaultMaxWait,
+			fn:      fn,
+		},
+		spec:     spec,
+		overlap:  CronOverlapSkip,
+		location: time.Local,
+	}
+	w.schedule, w.parseErr = ParseCronSpec(spec)
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	return func(cs *CoresService) {
+		cs.workers = append(cs.workers, w)
+	}
+}
+
+func (w *cronWorker) Name() string {
+	return w.name
+}
+
+func (w *cronWorker) Fn(ctx context.Context) error {
+	return w.run(ctx)
+}
+
+func (w *cronWorker) run(ctx context.Context) error {
+	if w.parseErr != nil {
+		return w.parseErr
+	}
+
+	var (
+		wg    sync.WaitGroup
+		queue chan time.Time
+	)
+	defer wg.Wait()
+
+	if w.overlap == CronOverlapQueue {
+		queue = make(chan time.Time, cronQueueSize)
+		defer close(queue)
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for scheduled := range queue {
+				w.execute(ctx, scheduled)
+			}
+		}()
+	}
+
+	innerlog.Logger.Debugc(ctx, "Cron worker(%s) scheduled. Spec=%s", w.name, w.spec)
+
+	for {
+		now := time.Now().In(w.location)
+		next := w.schedule.Next(now)
+		if next.IsZero() {
+			return errors.Errorf("cron worker(%s) spec(%s) has no next run", w.name, w.spec)
+		}
+
+		timer := time.NewTimer(next.Sub(now))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil
+		case <-timer.C:
+		}
+
+		switch w.overlap {
+		case CronOverlapQueue:
+			select {
+			case queue <- next:
+			default:
+				innerlog.Logger.Warnc(ctx, "Cron worker(%s) queue is full, skip run at %v", w.name, next)
+			}
+		case CronOverlapConcurrent:
+			wg.Add(1)
+			go func(scheduled time.Time) {
+				defer wg.Done()
+				w.execute(ctx, scheduled)
+			}(next)
+		default:
+			// 在启动 goroutine 前占用执行名额, 避免上一次任务仍在获取锁时本次触发也通过检查
+			if !w.running.CompareAndSwap(0, 1) {
+				innerlog.Logger.Warnc(ctx, "Cron worker(%s) previous run not finished, skip run at %v", w.name, next)
+				continue
+			}
+			wg.Add(1)
+			go func(scheduled time.Time) {
+				defer wg.Done()
+				defer w.running.Add(-1)
+				w.invoke(ctx, scheduled)
+			}(next)
+		}
+	}
+}
+
+func (w *cronWorker) acquire(ctx context.Context, scheduled time.Time) bool {
+	if w.locker == nil {
+		return true
+	}
+
+	key := w.lockKey
+	if key == "" {
+		key = fmt.Sprintf("cores:cron:%s", w.name)
+	}
+
+	ttl := w.lockTTL
+	interval := w.schedule.Next(scheduled).Sub(scheduled)
+	if ttl >= interval && interval > 0 {
+		w.ttlWarn.Do(func() {
+			innerlog.Logger.Warnc(ctx, "Cron worker(%s) lock ttl(%v) is not shorter than interval(%v), use half of interval", w.name, ttl, interval)
+		})
+		ttl = 0
+	}
+	if ttl <= 0 {
+		ttl = max(interval/2, time.Millisecond)
+	}
+
+	err := w.locker.TryLock(ctx, key, ttl)
+	if errors.Is(err, redisutils.ErrLockAcquireFailed) {
+		innerlog.Logger.Debugc(ctx, "Cron worker(%s) run at %v is taken by another instance", w.name, scheduled)
+		return false
+	} else if err != nil {
+		innerlog.Logger.Errorc(ctx, "Cron worker(%s) acquire lock failed: %v", w.name, err)
+		return false
+	}
+	return true
+}
+
+func (w *cronWorker) execute(ctx context.Context, scheduled time.Time) {
+	w.running.Add(1)
+	defer w.running.Add(-1)
+
+	w.invoke(ctx, scheduled)
+}
+
+// invoke 获取锁后执行一次任务, 调用方负责维护 running 计数
+func (w *cronWorker) invoke(ctx context.Context, scheduled time.Time) {
+	if ctx.Err() != nil || !w.acquire(ctx, scheduled) {
+		return
+	}
+
+	defer func() {
+		if e := recover(); e != nil {
+			innerlog.Logger.Errorc(ctx, "Cron worker(%s) panic: %v\n%s", w.name, e, debug.Stack())
+		}
+	}()
+
+	start := time.Now()
+	if err := w.fn(ctx); err != nil && !errors.Is(err, context.Canceled) {
+		innerlog.Logger.Errorc(ctx, "Cron worker(%s) run error: %v", w.name, err)
+		return
+	}
+	innerlog.Logger.Debugc(ctx, "Cron worker(%s) finished. Cost=%v", w.name, time.Since(start))
+}
+
+func (c *CoresService) mountCronWorker(worker *cronWorker) {
+	c.mountFns = append(c.mountFns, mountFn{
+		fn: func(ctx context.Context) error {
+			if err := worker.run(ctx); err != nil {
+				innerlog.Logger.Errorc(ctx, "worker: %v run error: %v\n", worker.name, err)
+				return errors.Wrapf(err, "cronWorker: %v run failed", worker.name)
+			}
+			return nil
+		},
+		maxWait: worker.maxWait,
+		name:    worker.name,
+	})
+}
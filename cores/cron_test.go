@@ -0,0 +1,201 @@
+package cores
+
+import (
+	"context"
+	"errors"
+	"io"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/miebyte/goutils/internal/innerlog"
+	"github.com/miebyte/goutils/redisutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCronWorkerSkipOverlap(t *testing.T) {
+	// 每次被跳过的触发都会打印警告, 触发间隔极短时丢弃日志
+	out := innerlog.Logger.Out
+	innerlog.Logger.SetOutput(io.Discard)
+	t.Cleanup(func() { innerlog.Logger.SetOutput(out) })
+
+	var runs, active, maxActive atomic.Int32
+	w := &cronWorker{
+		base: &base{name: "slow", fn: func(ctx context.Context) error {
+			n := active.Add(1)
+			defer active.Add(-1)
+			for {
+				m := maxActive.Load()
+				if n <= m || maxActive.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			runs.Add(1)
+			time.Sleep(time.Millisecond * 100)
+			return nil
+		}},
+		schedule: everySchedule{interval: time.Microsecond},
+		overlap:  CronOverlapSkip,
+		location: time.Local,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*350)
+	defer cancel()
+	require.NoError(t, w.run(ctx))
+
+	assert.Equal(t, int32(1), maxActive.Load())
+	assert.GreaterOrEqual(t, runs.Load(), int32(2))
+	assert.LessOrEqual(t, runs.Load(), int32(4))
+	assert.Zero(t, w.running.Load())
+}
+
+func TestCronWorkerConcurrentOverlap(t *testing.T) {
+	var active, maxActive atomic.Int32
+	w := &cronWorker{
+		base: &base{name: "slow", fn: func(ctx context.Context) error {
+			n := active.Add(1)
+			defer active.Add(-1)
+			for {
+				m := maxActive.Load()
+				if n <= m || maxActive.CompareAndSwap(m, n) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond * 100)
+			return nil
+		}},
+		schedule: everySchedule{interval: time.Millisecond * 10},
+		overlap:  CronOverlapConcurrent,
+		location: time.Local,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*200)
+	defer cancel()
+	require.NoError(t, w.run(ctx))
+
+	assert.Greater(t, maxActive.Load(), int32(1))
+}
+
+// limitedSchedule 每 interval 触发一次, 共触发 n 次, 第一次触发后的计算等待 wait 关闭
+type limitedSchedule struct {
+	interval time.Duration
+	n        int
+	wait     <-chan struct{}
+	calls    atomic.Int32
+}
+
+func (s *limitedSchedule) Next(t time.Time) time.Time {
+	calls := int(s.calls.Add(1))
+	if calls > s.n {
+		return time.Time{}
+	}
+	if calls > 1 {
+		<-s.wait
+	}
+	return t.Add(s.interval)
+}
+
+func TestCronWorkerQueueOverlap(t *testing.T) {
+	out := innerlog.Logger.Out
+	innerlog.Logger.SetOutput(io.Discard)
+	t.Cleanup(func() { innerlog.Logger.SetOutput(out) })
+
+	var (
+		runs, active, maxActive atomic.Int32
+		startOnce               sync.Once
+	)
+	started, release := make(chan struct{}), make(chan struct{})
+	schedule := &limitedSchedule{interval: time.Millisecond, n: cronQueueSize * 2, wait: started}
+	w := &cronWorker{
+		base: &base{name: "slow", fn: func(ctx context.Context) error {
+			n := active.Add(1)
+			defer active.Add(-1)
+			if n > maxActive.Load() {
+				maxActive.Store(n)
+			}
+			runs.Add(1)
+			startOnce.Do(func() { close(started) })
+			<-release
+			return nil
+		}},
+		schedule: schedule,
+		overlap:  CronOverlapQueue,
+		location: time.Local,
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- w.run(context.Background()) }()
+
+	// 第一次执行阻塞期间, 后续触发最多排队 cronQueueSize 个, 其余丢弃
+	assert.Eventually(t, func() bool {
+		return int(schedule.calls.Load()) > schedule.n
+	}, time.Second*3, time.Millisecond*10)
+	close(release)
+
+	// 触发结束后 run 返回前会执行完队列中的任务
+	assert.ErrorContains(t, <-done, "has no next run")
+	assert.EqualValues(t, cronQueueSize+1, runs.Load())
+	assert.EqualValues(t, 1, maxActive.Load())
+	assert.Zero(t, w.running.Load())
+}
+
+type fakeCronLocker struct {
+	mu   sync.Mutex
+	keys []string
+	ttls []time.Duration
+	err  error
+}
+
+func (l *fakeCronLocker) TryLock(ctx context.Context, key string, expiration time.Duration) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.keys = append(l.keys, key)
+	l.ttls = append(l.ttls, expiration)
+	return l.err
+}
+
+func TestCronWorkerLock(t *testing.T) {
+	var runs atomic.Int32
+	locker := &fakeCronLocker{}
+	w := &cronWorker{
+		base: &base{name: "job", fn: func(ctx context.Context) error {
+			runs.Add(1)
+			return nil
+		}},
+		schedule: everySchedule{interval: time.Minute},
+		locker:   locker,
+	}
+	ctx := context.Background()
+	scheduled := time.Now()
+
+	// 默认 key 与 ttl 为间隔的一半
+	w.invoke(ctx, scheduled)
+	assert.EqualValues(t, 1, runs.Load())
+
+	w.lockKey, w.lockTTL = "custom", time.Second*10
+	w.invoke(ctx, scheduled)
+
+	// ttl 不小于间隔时按间隔的一半处理
+	w.lockTTL = time.Minute
+	w.invoke(ctx, scheduled)
+
+	assert.Equal(t, []string{"cores:cron:job", "custom", "custom"}, locker.keys)
+	assert.Equal(t, []time.Duration{time.Second * 30, time.Second * 10, time.Second * 30}, locker.ttls)
+	assert.EqualValues(t, 3, runs.Load())
+
+	// 其他实例持有锁或获取锁出错时不执行
+	locker.err = redisutils.ErrLockAcquireFailed
+	w.invoke(ctx, scheduled)
+	locker.err = errors.New("connection refused")
+	w.invoke(ctx, scheduled)
+	assert.EqualValues(t, 3, runs.Load())
+}
+
+func TestWithCronRedisLockNilClient(t *testing.T) {
+	w := &cronWorker{base: &base{}}
+	WithCronRedisLock(nil, "key", time.Second)(w)
+	assert.Nil(t, w.locker)
+	assert.True(t, w.acquire(context.Background(), time.Now()))
+}
@@ -0,0 +1,274 @@
+// File:		cronspec.go
+// Created by:	Hoven
+// Created on:	2026-10-17
+//
+// This file is part of the Example Project.
+//
+// (c) 2024 Example Corp. All rights reserved.
+
+package cores
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// CronSchedule 返回给定时间之后的下一次触发时间, 没有下一次时返回零值
+type CronSchedule interface {
+	Next(t time.Time) time.Time
+}
+
+type cronField struct {
+	min, max int
+	names    map[string]int
+}
+
+var (
+	secondField = cronField{min: 0, max: 59}
+	minuteField = cronField{min: 0, max: 59}
+	hourField   = cronField{min: 0, max: 23}
+	domField    = cronField{min: 1, max: 31}
+	monthField  = cronField{min: 1, max: 12, names: map[string]int{
+		"jan": 1, "feb": 2, "mar": 3, "apr": 4, "may": 5, "jun": 6,
+		"jul": 7, "aug": 8, "sep": 9, "oct": 10, "nov": 11, "dec": 12,
+	}}
+	dowField = cronField{min: 0, max: 6, names: map[string]int{
+		"sun": 0, "mon": 1, "tue": 2, "wed": 3, "thu": 4, "fri": 5, "sat": 6,
+	}}
+
+	cronDescriptors = map[string]string{
+		"@yearly":   "0 0 0 1 1 *",
+		"@annually": "0 0 0 1 1 *",
+		"@monthly":  "0 0 0 1 * *",
+		"@weekly":   "0 0 0 * * 0",
+		"@daily":    "0 0 0 * * *",
+		"@midnight": "0 0 0 * * *",
+		"@hourly":   "0 0 * * * *",
+	}
+)
+
+// specSchedule 以位图表示每个字段允许的取值
+type specSchedule struct {
+	second, minute, hour, dom, month, dow uint64
+	domStar, dowStar                      bool
+}
+
+type everySchedule struct {
+	interval time.Duration
+}
+
+func (s everySchedule) Next(t time.Time) time.Time {
+	return t.Add(s.interval)
+}
+
+// ParseCronSpec 解析 cron 表达式
+//
+// 支持标准 5 段(分 时 日 月 周)与 6 段(秒 分 时 日 月 周)表达式,
+// 以及 @yearly/@monthly/@weekly/@daily/@hourly 与 "@every 5m" 简写。
+func ParseCronSpec(spec string) (CronSchedule, error) {
+	spec = strings.TrimSpace(spec)
+	if spec == "" {
+		return nil, errors.New("empty cron spec")
+	}
+
+	if strings.HasPrefix(spec, "@every ") {
+		d, err := time.ParseDuration(strings.TrimSpace(strings.TrimPrefix(spec, "@every ")))
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse cron spec(%s)", spec)
+		}
+		if d < time.Second {
+			return nil, errors.Errorf("cron spec(%s) interval must be at least 1s", spec)
+		}
+		return everySchedule{interval: d}, nil
+	}
+
+	if strings.HasPrefix(spec, "@") {
+		expanded, ok := cronDescriptors[strings.ToLower(spec)]
+		if !ok {
+			return nil, errors.Errorf("unknown cron descriptor(%s)", spec)
+		}
+		spec = expanded
+	}
+
+	fields := strings.Fields(spec)
+	switch len(fields) {
+	case 5:
+		fields = append([]string{"0"}, fields...)
+	case 6:
+	default:
+		return nil, errors.Errorf("cron spec(%s) expected 5 or 6 fields, got %d", spec, len(fields))
+	}
+
+	var (
+		s   = &specSchedule{}
+		err error
+	)
+	parsers := []struct {
+		target *uint64
+		field  cronField
+	}{
+		{&s.second, secondField},
+		{&s.minute, minuteField},
+		{&s.hour, hourField},
+		{&s.dom, domField},
+		{&s.month, monthField},
+		{&s.dow, dowField},
+	}
+	for idx, p := range parsers {
+		*p.target, err = parseCronField(fields[idx], p.field)
+		if err != nil {
+			return nil, errors.Wrapf(err, "parse cron spec(%s)", spec)
+		}
+	}
+
+	s.domStar = isCronStar(fields[3])
+	s.dowStar = isCronStar(fields[5])
+	return s, nil
+}
+
+func isCronStar(field string) bool {
+	return field == "*" || field == "?"
+}
+
+func parseCronField(field string, cf cronField) (uint64, error) {
+	var bitsSet uint64
+	for _, expr := range strings.Split(field, ",") {
+		b, err := parseCronRange(expr, cf)
+		if err != nil {
+			return 0, err
+		}
+		bitsSet |= b
+	}
+	return bitsSet, nil
+}
+
+func parseCronRange(expr string, cf cronField) (uint64, error) {
+	var (
+		start, end, step = cf.min, cf.max, 1
+		err              error
+	)
+
+	rangeExpr, stepExpr, hasStep := strings.Cut(expr, "/")
+	if hasStep {
+		step, err = strconv.Atoi(stepExpr)
+		if err != nil || step <= 0 {
+			return 0, errors.Errorf("invalid step(%s)", expr)
+		}
+	}
+
+	switch {
+	case isCronStar(rangeExpr):
+	default:
+		lo, hi, isRange := strings.Cut(rangeExpr, "-")
+		if start, err = parseCronValue(lo, cf); err != nil {
+			return 0, err
+		}
+		end = start
+		if isRange {
+			if end, err = parseCronValue(hi, cf); err != nil {
+				return 0, err
+			}
+		} else if hasStep {
+			end = cf.max
+		}
+	}
+
+	if start > end {
+		return 0, errors.Errorf("invalid range(%s)", expr)
+	}
+
+	var b uint64
+	for v := start; v <= end; v += step {
+		b |= 1 << uint(v)
+	}
+
+	// 周日同时允许 0 与 7
+	if cf.max == dowField.max && b&(1<<7) != 0 {
+		b = b&^(1<<7) | 1
+	}
+	return b, nil
+}
+
+func parseCronValue(v string, cf cronField) (int, error) {
+	if n, ok := cf.names[strings.ToLower(v)]; ok {
+		return n, nil
+	}
+
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return 0, errors.Errorf("invalid value(%s)", v)
+	}
+
+	maxValue := cf.max
+	if cf.max == dowField.max {
+		maxValue = 7
+	}
+	if n < cf.min || n > maxValue {
+		return 0, errors.Errorf("value(%d) out of range [%d, %d]", n, cf.min, maxValue)
+	}
+	return n, nil
+}
+
+func (s *specSchedule) dayMatches(t time.Time) bool {
+	domMatch := s.dom&(1<<uint(t.Day())) != 0
+	dowMatch := s.dow&(1<<uint(t.Weekday())) != 0
+	if s.domStar || s.dowStar {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+func (s *specSchedule) Next(t time.Time) time.Time {
+	if s.dom == 0 || s.month == 0 {
+		return time.Time{}
+	}
+
+	loc := t.Location()
+	t = t.Truncate(time.Second).Add(time.Second)
+	yearLimit := t.Year() + 5
+
+WRAP:
+	if t.Year() > yearLimit {
+		return time.Time{}
+	}
+
+	for s.month&(1<<uint(t.Month())) == 0 {
+		t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		if t.Month() == time.January {
+			goto WRAP
+		}
+	}
+
+	for !s.dayMatches(t) {
+		t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		if t.Day() == 1 {
+			goto WRAP
+		}
+	}
+
+	for s.hour&(1<<uint(t.Hour())) == 0 {
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		if t.Hour() == 0 {
+			goto WRAP
+		}
+	}
+
+	for s.minute&(1<<uint(t.Minute())) == 0 {
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, loc)
+		if t.Minute() == 0 {
+			goto WRAP
+		}
+	}
+
+	for s.second&(1<<uint(t.Second())) == 0 {
+		t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute(), t.Second()+1, 0, loc)
+		if t.Second() == 0 {
+			goto WRAP
+		}
+	}
+
+	return t
+}
@@ -0,0 +1,103 @@
+package cores
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCronSpecNext(t *testing.T) {
+	base := time.Date(2025, time.March, 14, 10, 7, 30, 0, time.UTC) // Friday
+
+	tests := []struct {
+		name     string
+		spec     string
+		expected time.Time
+	}{
+		{
+			name:     "every minute",
+			spec:     "* * * * *",
+			expected: time.Date(2025, time.March, 14, 10, 8, 0, 0, time.UTC),
+		},
+		{
+			name:     "every 15 minutes",
+			spec:     "*/15 * * * *",
+			expected: time.Date(2025, time.March, 14, 10, 15, 0, 0, time.UTC),
+		},
+		{
+			name:     "with seconds",
+			spec:     "45 7 10 * * *",
+			expected: time.Date(2025, time.March, 14, 10, 7, 45, 0, time.UTC),
+		},
+		{
+			name:     "daily at 2:30",
+			spec:     "30 2 * * *",
+			expected: time.Date(2025, time.March, 15, 2, 30, 0, 0, time.UTC),
+		},
+		{
+			name:     "weekday names",
+			spec:     "0 9 * * mon-wed",
+			expected: time.Date(2025, time.March, 17, 9, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "sunday as 7",
+			spec:     "0 0 * * 7",
+			expected: time.Date(2025, time.March, 16, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "dom or dow",
+			spec:     "0 0 20 * 6",
+			expected: time.Date(2025, time.March, 15, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "month wrap to next year",
+			spec:     "0 0 1 jan *",
+			expected: time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "leap day",
+			spec:     "0 0 29 2 *",
+			expected: time.Date(2028, time.February, 29, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "hourly descriptor",
+			spec:     "@hourly",
+			expected: time.Date(2025, time.March, 14, 11, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "every duration",
+			spec:     "@every 5m",
+			expected: base.Add(5 * time.Minute),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := ParseCronSpec(tt.spec)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, schedule.Next(base))
+		})
+	}
+}
+
+func TestParseCronSpecInvalid(t *testing.T) {
+	specs := []string{
+		"",
+		"* * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"@every 100ms",
+		"@unknown",
+	}
+
+	for _, spec := range specs {
+		_, err := ParseCronSpec(spec)
+		assert.Error(t, err, spec)
+	}
+}
@@ -0,0 +1,207 @@
+// File:		debug.go
+// Created by:	Hoven
+// Created on:	2026-10-17
+//
+// This file is part of the Example Project.
+//
+// (c) 2024 Example Corp. All rights reserved.
+
+package cores
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"runtime"
+	"time"
+
+	"github.com/miebyte/goutils/buildinfo"
+	"github.com/miebyte/goutils/flags"
+	innerbuildinfo "github.com/miebyte/goutils/internal/buildinfo"
+	"github.com/miebyte/goutils/internal/innerlog"
+	"github.com/miebyte/goutils/internal/share"
+	"github.com/miebyte/goutils/logging"
+	"github.com/miebyte/goutils/logging/level"
+)
+
+const (
+	debugUrl = "/debug/cores"
+)
+
+type debugService struct {
+	Name      string   `json:"name"`
+	Tags      []string `json:"tags"`
+	Debug     bool     `json:"debug"`
+	Host      string   `json:"host"`
+	Pid       int      `json:"pid"`
+	StartedAt string   `json:"started_at,omitempty"`
+	Uptime    string   `json:"uptime,omitempty"`
+}
+
+type debugBuild struct {
+	Version       string `json:"version"`
+	ModulePath    string `json:"module_path"`
+	ModuleVersion string `json:"module_version"`
+	GoVersion     string `json:"go_version"`
+}
+
+type debugListener struct {
+	Name string `json:"name"`
+	Addr string `json:"addr"`
+}
+
+type debugReport struct {
+	Service      debugService    `json:"service"`
+	Build        debugBuild      `json:"build"`
+	LogLevel     string          `json:"log_level"`
+	Listeners    []debugListener `json:"listeners"`
+	HttpPatterns []string        `json:"http_patterns"`
+	Workers      []WorkerStatus  `json:"workers"`
+	Config       map[string]any  `json:"config"`
+}
+
+type debugLogLevelRequest struct {
+	Level string `json:"level"`
+}
+
+// DebugAuthFunc 校验 /debug/cores 的请求, 返回错误时以 403 拒绝请求
+type DebugAuthFunc func(r *http.Request) error
+
+// WithDebugEndpoint 开启 /debug/cores 管理端点
+//
+// GET 返回 worker 状态, http 路由, 服务信息, 构建信息及脱敏后的当前配置;
+// POST {"level": "debug"} 可在运行时调整日志级别。
+// 该端点只挂载在独立的管理监听(AdminListener)上, 未配置管理监听时不会开启,
+// 如需在业务监听上开启请使用 WithDebugEndpointAuth。
+func WithDebugEndpoint() ServiceOption {
+	return func(cs *CoresService) {
+		cs.useDebug = true
+	}
+}
+
+// WithDebugEndpointAuth 开启 /debug/cores 管理端点, 每个请求都需通过 auth 校验
+// 未配置独立的管理监听时, 端点会挂载在业务监听上
+func WithDebugEndpointAuth(auth DebugAuthFunc) ServiceOption {
+	return func(cs *CoresService) {
+		cs.useDebug = true
+		cs.debugAuth = auth
+	}
+}
+
+func (c *CoresService) setupDebug() {
+	if !c.useDebug {
+		return
+	}
+
+	addr := c.adminAddr()
+	if addr == "" {
+		innerlog.Logger.Warnf("Cores server not start by cores.Start(). Debug endpoint can not be enabled")
+		return
+	}
+
+	// 端点可以修改日志级别, 不允许在未鉴权的情况下暴露在业务监听上
+	mux := c.adminHttpMux
+	if mux == nil {
+		if c.debugAuth == nil {
+			innerlog.Logger.Warnf("Debug endpoint requires an admin listener or WithDebugEndpointAuth, not enabled")
+			return
+		}
+		mux = c.httpMux
+	}
+	mux.HandleFunc(debugUrl, c.debugApi)
+
+	_, port, _ := net.SplitHostPort(addr)
+	target := fmt.Sprintf("localhost:%s", port)
+	innerlog.Logger.Debugf("Debug endpoint enabled. URL=%s", fmt.Sprintf("http://%s%s", target, debugUrl))
+}
+
+func (c *CoresService) debugApi(w http.ResponseWriter, r *http.Request) {
+	if c.debugAuth != nil {
+		if err := c.debugAuth(r); err != nil {
+			innerlog.Logger.Warnc(r.Context(), "Debug endpoint request rejected. Remote=%s err: %v", r.RemoteAddr, err)
+			http.Error(w, http.StatusText(http.StatusForbidden), http.StatusForbidden)
+			return
+		}
+	}
+
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		writeDebugJson(w, http.StatusOK, c.debugReport())
+	case http.MethodPost:
+		c.setLogLevel(w, r)
+	default:
+		w.Header().Set("Allow", "GET, HEAD, POST")
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+	}
+}
+
+func (c *CoresService) debugReport() *debugReport {
+	report := &debugReport{
+		Service: debugService{
+			Name:  share.ServiceName(),
+			Tags:  c.tags,
+			Debug: share.Debug(),
+			Host:  innerbuildinfo.GetHost(),
+			Pid:   innerbuildinfo.Pid(),
+		},
+		Build: debugBuild{
+			Version:       buildinfo.Version,
+			ModulePath:    innerbuildinfo.GetModulePath(),
+			ModuleVersion: innerbuildinfo.GetModuleVersion(),
+			GoVersion:     runtime.Version(),
+		},
+		LogLevel:     logging.GetLevel().String(),
+		HttpPatterns: c.httpPatterns,
+		Workers:      c.WorkerStatuses(),
+		Config:       flags.AllSettingsRedacted(),
+	}
+
+	if report.Service.Name == "" {
+		report.Service.Name = c.serviceName
+	}
+	if !c.startedAt.IsZero() {
+		report.Service.StartedAt = c.startedAt.Format(time.RFC3339)
+		report.Service.Uptime = time.Since(c.startedAt).Round(time.Second).String()
+	}
+	for _, nl := range c.listeners {
+		report.Listeners = append(report.Listeners, debugListener{Name: nl.name, Addr: nl.localAddr})
+	}
+	return report
+}
+
+func (c *CoresService) setLogLevel(w http.ResponseWriter, r *http.Request) {
+	req := &debugLogLevelRequest{Level: r.URL.Query().Get("level")}
+	if req.Level == "" {
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<10)).Decode(req); err != nil {
+			http.Error(w, fmt.Sprintf("invalid request body: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+
+	lev, err := level.ParseLevel(req.Level)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	previous := logging.GetLevel()
+	logging.Enable(lev)
+	innerlog.Logger.Enable(lev)
+	innerlog.Logger.Infoc(r.Context(), "Log level changed by debug endpoint. From=%s To=%s Remote=%s", previous, lev, r.RemoteAddr)
+
+	writeDebugJson(w, http.StatusOK, map[string]string{
+		"previous":  previous.String(),
+		"log_level": lev.String(),
+	})
+}
+
+func writeDebugJson(w http.ResponseWriter, code int, data any) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(data)
+}
@@ -0,0 +1,38 @@
+package cores
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDebugEndpointRequiresAdminListenerOrAuth(t *testing.T) {
+	cs := NewCores(WithDebugEndpoint())
+	cs.listenAddr = "127.0.0.1:8080"
+	cs.setupDebug()
+
+	_, pattern := cs.httpMux.Handler(httptest.NewRequest(http.MethodGet, debugUrl, nil))
+	assert.Empty(t, pattern)
+
+	cs = NewCores(WithDebugEndpointAuth(func(r *http.Request) error {
+		if r.Header.Get("X-Debug-Token") != "secret" {
+			return errors.New("invalid token")
+		}
+		return nil
+	}))
+	cs.listenAddr = "127.0.0.1:8080"
+	cs.setupDebug()
+
+	rec := httptest.NewRecorder()
+	cs.httpMux.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, debugUrl+"?level=debug", nil))
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+
+	req := httptest.NewRequest(http.MethodGet, debugUrl, nil)
+	req.Header.Set("X-Debug-Token", "secret")
+	rec = httptest.NewRecorder()
+	cs.httpMux.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
@@ -12,6 +12,7 @@ import (
 	"context"
 	"os"
 	"os/signal"
+	"slices"
 	"syscall"
 	"time"
 
@@ -19,42 +20,110 @@ import (
 	"github.com/pkg/errors"
 )
 
+var (
+	defaultSignals = []os.Signal{
+		os.Interrupt,
+		syscall.SIGHUP,
+		syscall.SIGINT,
+		syscall.SIGTERM,
+		syscall.SIGQUIT,
+	}
+)
+
 func (c *CoresService) gracefulKill() mountFn {
 	return mountFn{
-		name:    "GracefulKill",
-		maxWait: time.Second * 3,
+		name:      "GracefulKill",
+		lifecycle: true,
 		fn: func(ctx context.Context) error {
+			var signals []os.Signal
+			if !c.disableSignals {
+				signals = c.signals
+				if signals == nil {
+					signals = defaultSignals
+				}
+			}
+			if c.gracefulRestart && restartSignal != nil {
+				signals = append(slices.Clone(signals), restartSignal)
+			}
+
 			ch := make(chan os.Signal, 1)
-			signal.Notify(
-				ch,
-				os.Interrupt,
-				syscall.SIGHUP,
-				syscall.SIGINT,
-				syscall.SIGTERM,
-				syscall.SIGQUIT,
+			if len(signals) != 0 {
+				signal.Notify(ch, signals...)
+				defer signal.Stop(ch)
+			}
+
+			// 热重启在后台等待新进程就绪, 期间仍可响应退出信号
+			var (
+				handoffDone   chan error
+				cancelHandoff context.CancelFunc
 			)
+			abortHandoff := func() {
+				if handoffDone != nil {
+					cancelHandoff()
+					<-handoffDone
+					handoffDone = nil
+				}
+			}
+			defer abortHandoff()
 
-			select {
-			case sg := <-ch:
+			stopBySignal := func(sg os.Signal) error {
 				innerlog.Logger.Infoc(ctx, "Graceful stopping service... Signal: %s", sg)
+				c.shutdown(ctx, "signal: "+sg.String())
+				return errors.Errorf("Signal: %s", sg.String())
+			}
 
-				if c.httpServer != nil {
-					_ = c.httpServer.Shutdown(ctx)
-					innerlog.Logger.Infoc(ctx, "Graceful stopped http server")
-				}
-
-				if c.listener != nil {
-					_ = c.listener.Close()
-					innerlog.Logger.Infoc(ctx, "Graceful stopped listener")
-				}
+			for {
+				select {
+				case sg := <-ch:
+					if c.gracefulRestart && sg == restartSignal {
+						if handoffDone != nil {
+							innerlog.Logger.Warnc(ctx, "Graceful restart is in progress, ignore signal: %s", sg)
+							continue
+						}
 
-				c.cancel()
+						handoffCtx, cancel := context.WithCancel(ctx)
+						cancelHandoff = cancel
+						done := make(chan error, 1)
+						handoffDone = done
+						go func() {
+							done <- c.handoff(handoffCtx)
+						}()
+						continue
+					}
 
-				innerlog.Logger.Infoc(ctx, "Graceful stopped service successfully")
-				return errors.Errorf("Signal: %s", sg.String())
-			case <-ctx.Done():
-				return ctx.Err()
+					abortHandoff()
+					return stopBySignal(sg)
+				case err := <-handoffDone:
+					handoffDone = nil
+					cancelHandoff()
+					if err != nil {
+						innerlog.Logger.Errorc(ctx, "Graceful restart failed, keep serving: %v", err)
+						continue
+					}
+					return stopBySignal(restartSignal)
+				case <-c.parentCtx.Done():
+					abortHandoff()
+					innerlog.Logger.Infoc(ctx, "Graceful stopping service... Parent context done: %v", c.parentCtx.Err())
+					c.shutdown(ctx, "context: "+c.parentCtx.Err().Error())
+					return nil
+				case <-ctx.Done():
+					// 由 Shutdown 或 worker 退出触发, 错误由触发方返回
+					return nil
+				}
 			}
 		},
 	}
 }
+
+// shutdown 将就绪检查置为失败, 等待流量摘除后按顺序执行停止 hook
+func (c *CoresService) shutdown(ctx context.Context, reason string) {
+	c.setShutdownReason(reason)
+	c.markShuttingDown()
+	if c.readinessDrainDelay > 0 {
+		innerlog.Logger.Infoc(ctx, "Readiness marked failing, waiting %v before stopping http server", c.readinessDrainDelay)
+		time.Sleep(c.readinessDrainDelay)
+	}
+
+	c.stopHooks()
+	innerlog.Logger.Infoc(ctx, "Graceful stopped service successfully")
+}
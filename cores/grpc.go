@@ -0,0 +1,354 @@
+// File:		grpc.go
+// Created by:	Hoven
+// Created on:	2026-10-17
+//
+// This file is part of the Example Project.
+//
+// (c) 2024 Example Corp. All rights reserved.
+
+package cores
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miebyte/goutils/internal/innerlog"
+	"github.com/miebyte/goutils/logging"
+	"github.com/pkg/errors"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	grpcServerName = "GrpcServer"
+)
+
+var (
+	defaultGrpcHealthInterval = time.Second * 5
+)
+
+type grpcService struct {
+	listener       string
+	serverOpts     []grpc.ServerOption
+	registers      []func(srv *grpc.Server)
+	healthInterval time.Duration
+
+	server *grpc.Server
+	health *health.Server
+
+	// 共用监听时跟踪进行中的调用, 以便退出时排空
+	mu       sync.Mutex
+	stopping bool
+	inflight sync.WaitGroup
+}
+
+type GrpcOption func(*grpcService)
+
+// WithGrpcListener 使用独立的命名监听提供 gRPC 服务, 监听需通过 WithListener 声明
+// 未设置时 gRPC 与 http 共用默认监听, 按 content-type 分流
+func WithGrpcListener(name string) GrpcOption {
+	return func(gs *grpcService) {
+		gs.listener = name
+	}
+}
+
+// WithGrpcServerOptions 追加 grpc.NewServer 的参数, 如拦截器, 证书等
+func WithGrpcServerOptions(opts ...grpc.ServerOption) GrpcOption {
+	return func(gs *grpcService) {
+		gs.serverOpts = append(gs.serverOpts, opts...)
+	}
+}
+
+// WithGrpcHealthInterval 设置根据就绪检查刷新 gRPC 健康状态的间隔, 默认 5s
+func WithGrpcHealthInterval(interval time.Duration) GrpcOption {
+	return func(gs *grpcService) {
+		if interval > 0 {
+			gs.healthInterval = interval
+		}
+	}
+}
+
+// WithGrpcServer 挂载 gRPC 服务, register 中完成服务注册
+//
+// cores 会注册标准的 grpc.health.v1 健康检查服务, 其状态跟随 /readyz 的就绪状态,
+// 并为所有调用添加日志拦截器。多次调用时注册到同一个 gRPC 服务上。
+//
+// 与 http 共用监听时, 未开启 TLS 的情况下通过 h2c 提供 HTTP/2 服务。
+// 共用监听的 gRPC 调用不受 HttpServerConfig 的 ReadTimeout/WriteTimeout 限制,
+// 以免流式调用或耗时较长的调用被截断, 如需限制请在客户端设置 deadline 或使用拦截器。
+func WithGrpcServer(register func(srv *grpc.Server), opts ...GrpcOption) ServiceOption {
+	return func(cs *CoresService) {
+		if cs.grpc == nil {
+			cs.grpc = &grpcService{healthInterval: defaultGrpcHealthInterval}
+		}
+
+		if register != nil {
+			cs.grpc.registers = append(cs.grpc.registers, register)
+		}
+		for _, opt := range opts {
+			opt(cs.grpc)
+		}
+	}
+}
+
+// sharedListener gRPC 是否与 http 共用默认监听
+func (gs *grpcService) sharedListener() bool {
+	return gs.listener == "" || gs.listener == DefaultListener
+}
+
+func (c *CoresService) setupGrpc() error {
+	gs := c.grpc
+	if gs == nil {
+		return nil
+	}
+
+	if gs.sharedListener() {
+		if c.listener == nil {
+			return errors.New("grpc server shares the default listener, but cores not start by cores.Start()")
+		}
+	} else if nl := c.getListener(gs.listener); nl == nil {
+		return errors.Errorf("grpc server listener(%s) not found", gs.listener)
+	}
+
+	opts := append([]grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(grpcUnaryLogging),
+		grpc.ChainStreamInterceptor(grpcStreamLogging),
+	}, gs.serverOpts...)
+	gs.server = grpc.NewServer(opts...)
+
+	gs.health = health.NewServer()
+	gs.health.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+	healthpb.RegisterHealthServer(gs.server, gs.health)
+
+	for _, register := range gs.registers {
+		register(gs.server)
+	}
+
+	if !gs.sharedListener() {
+		c.servers = append(c.servers, &mountedServer{
+			name:     grpcServerName,
+			listener: gs.listener,
+			server:   &grpcServer{server: gs.server},
+		})
+	}
+
+	c.mountFns = append(c.mountFns, mountFn{
+		name: "GrpcHealth",
+		fn:   c.watchGrpcHealth,
+	})
+	return nil
+}
+
+// watchGrpcHealth 定期执行就绪检查并同步到 gRPC 健康检查服务
+func (c *CoresService) watchGrpcHealth(ctx context.Context) error {
+	gs := c.grpc
+	ticker := time.NewTicker(gs.healthInterval)
+	defer ticker.Stop()
+
+	for {
+		servingStatus := healthpb.HealthCheckResponse_SERVING
+		if c.shuttingDown.Load() || runHealthChecks(ctx, c.readinessChecks).Status != healthStatusOk {
+			servingStatus = healthpb.HealthCheckResponse_NOT_SERVING
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		gs.setServingStatus(servingStatus)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+func (gs *grpcService) setServingStatus(servingStatus healthpb.HealthCheckResponse_ServingStatus) {
+	gs.health.SetServingStatus("", servingStatus)
+	for service := range gs.server.GetServiceInfo() {
+		if service != healthpb.Health_ServiceDesc.ServiceName {
+			gs.health.SetServingStatus(service, servingStatus)
+		}
+	}
+}
+
+// markGrpcShuttingDown 进入优雅退出后 gRPC 健康检查立即返回 NOT_SERVING, 且不再更新
+func (c *CoresService) markGrpcShuttingDown() {
+	if c.grpc != nil && c.grpc.health != nil {
+		c.grpc.health.Shutdown()
+	}
+}
+
+// grpcHandler 共用监听时, 将 HTTP/2 且 content-type 为 application/grpc 的请求交给 gRPC 服务
+func (c *CoresService) grpcHandler(handler http.Handler) http.Handler {
+	gs := c.grpc
+	if gs == nil || gs.server == nil || !gs.sharedListener() {
+		return handler
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ProtoMajor == 2 && strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+			gs.serveHTTP(w, r)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+func (gs *grpcService) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	gs.mu.Lock()
+	if gs.stopping {
+		gs.mu.Unlock()
+		w.Header().Set("Content-Type", "application/grpc")
+		w.Header().Set("Grpc-Status", strconv.Itoa(int(codes.Unavailable)))
+		w.Header().Set("Grpc-Message", "server is shutting down")
+		w.WriteHeader(http.StatusOK)
+		return
+	}
+	gs.inflight.Add(1)
+	gs.mu.Unlock()
+	defer gs.inflight.Done()
+
+	// http.Server 的 ReadTimeout/WriteTimeout 作用于每个 HTTP/2 stream, 会截断流式调用, 对 gRPC 调用取消该限制
+	rc := http.NewResponseController(w)
+	_ = rc.SetReadDeadline(time.Time{})
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	gs.server.ServeHTTP(w, r)
+}
+
+// stopSharedGrpc 共用监听时, 拒绝新的调用并在 ctx 结束前等待进行中的调用完成
+//
+// ServeHTTP 模式下的 transport 不支持 Drain, 存在连接时调用 GracefulStop 会 panic,
+// 因此先自行排空调用, 排空后再 GracefulStop, 超时则 Stop 强制关闭剩余调用。
+func (c *CoresService) stopSharedGrpc(ctx context.Context) {
+	gs := c.grpc
+	if gs == nil || gs.server == nil || !gs.sharedListener() {
+		return
+	}
+
+	gs.mu.Lock()
+	gs.stopping = true
+	gs.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		gs.inflight.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		gs.server.GracefulStop()
+		innerlog.Logger.Infoc(ctx, "Graceful stopped grpc server")
+	case <-ctx.Done():
+		gs.server.Stop()
+		innerlog.Logger.Warnc(ctx, "Grpc server graceful stop timeout, force closed")
+	}
+}
+
+// grpcServer 将 *grpc.Server 适配为 Server, 退出时 GracefulStop, 超时后强制 Stop
+type grpcServer struct {
+	server *grpc.Server
+}
+
+func (gs *grpcServer) Serve(lst net.Listener) error {
+	err := gs.server.Serve(lst)
+	if errors.Is(err, grpc.ErrServerStopped) {
+		return nil
+	}
+	return err
+}
+
+func (gs *grpcServer) Shutdown(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		gs.server.GracefulStop()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		gs.server.Stop()
+		return errors.Wrap(ctx.Err(), "grpc graceful stop timeout")
+	}
+}
+
+func grpcLogContext(ctx context.Context, method string) context.Context {
+	ctx = logging.With(ctx, "GrpcMethod", method)
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		ctx = logging.With(ctx, "Peer", p.Addr.String())
+	}
+	return ctx
+}
+
+func logGrpcCall(ctx context.Context, start time.Time, err error) {
+	if err != nil {
+		logging.Errorc(ctx, "grpc call failed. Code=%s Cost=%v Err=%v", status.Code(err), time.Since(start), err)
+		return
+	}
+	logging.Infoc(ctx, "grpc call finished. Code=%s Cost=%v", status.Code(err), time.Since(start))
+}
+
+// isGrpcHealthMethod 健康检查调用频繁, 不记录日志
+func isGrpcHealthMethod(method string) bool {
+	return strings.HasPrefix(method, "/"+healthpb.Health_ServiceDesc.ServiceName+"/")
+}
+
+func grpcUnaryLogging(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if isGrpcHealthMethod(info.FullMethod) {
+		return handler(ctx, req)
+	}
+
+	ctx = grpcLogContext(ctx, info.FullMethod)
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	logGrpcCall(ctx, start, err)
+	return resp, err
+}
+
+type loggingServerStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *loggingServerStream) Context() context.Context {
+	return s.ctx
+}
+
+func grpcStreamLogging(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+	if isGrpcHealthMethod(info.FullMethod) {
+		return handler(srv, ss)
+	}
+
+	ctx := grpcLogContext(ss.Context(), info.FullMethod)
+	start := time.Now()
+	err := handler(srv, &loggingServerStream{ServerStream: ss, ctx: ctx})
+	logGrpcCall(ctx, start, err)
+	return err
+}
+
+func (c *CoresService) grpcServices() []string {
+	if c.grpc == nil || c.grpc.server == nil {
+		return nil
+	}
+
+	var services []string
+	for service := range c.grpc.server.GetServiceInfo() {
+		services = append(services, service)
+	}
+	slices.Sort(services)
+	return services
+}
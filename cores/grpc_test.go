@@ -0,0 +1,63 @@
+package cores
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+func TestSharedGrpcStreamOutlivesHttpTimeouts(t *testing.T) {
+	lst, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	cs := NewCores(
+		WithoutSignals(),
+		WithHttpServerConfig(&HttpServerConfig{ReadTimeout: time.Millisecond * 200, WriteTimeout: time.Millisecond * 200}),
+		WithGrpcServer(nil, WithGrpcHealthInterval(time.Millisecond*50)),
+	)
+	go Serve(cs, lst)
+	select {
+	case <-cs.Started():
+	case <-time.After(time.Second * 5):
+		t.Fatal("service not started")
+	}
+
+	conn, err := grpc.NewClient(lst.Addr().String(), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	require.NoError(t, err)
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second*5)
+	defer cancel()
+	stream, err := healthpb.NewHealthClient(conn).Watch(ctx, &healthpb.HealthCheckRequest{})
+	require.NoError(t, err)
+
+	for {
+		resp, err := stream.Recv()
+		require.NoError(t, err)
+		if resp.Status == healthpb.HealthCheckResponse_SERVING {
+			break
+		}
+	}
+
+	// 超过 http 的读写超时后, 流仍然可以收到退出时的状态变更
+	time.Sleep(time.Millisecond * 500)
+	go cs.Shutdown(context.Background())
+
+	resp, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.Status)
+	cancel()
+
+	select {
+	case <-cs.Done():
+	case <-time.After(time.Second * 5):
+		t.Fatal("service not stopped")
+	}
+}
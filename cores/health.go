@@ -0,0 +1,193 @@
+// File:		health.go
+// Created by:	Hoven
+// Created on:	2026-10-17
+//
+// This file is part of the Example Project.
+//
+// (c) 2024 Example Corp. All rights reserved.
+
+package cores
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	livezUrl  = "/livez"
+	readyzUrl = "/readyz"
+
+	healthStatusOk   = "ok"
+	healthStatusFail = "failed"
+)
+
+var (
+	defaultHealthCheckTimeout = time.Second * 3
+
+	errShuttingDown = errors.New("service is shutting down")
+)
+
+// HealthChecker 健康检查接口, 返回 nil 表示健康
+type HealthChecker interface {
+	HealthCheck(ctx context.Context) error
+}
+
+// HealthCheckerFunc 将函数适配为 HealthChecker
+type HealthCheckerFunc func(ctx context.Context) error
+
+func (f HealthCheckerFunc) HealthCheck(ctx context.Context) error {
+	return f(ctx)
+}
+
+type healthCheck struct {
+	name    string
+	timeout time.Duration
+	checker HealthChecker
+}
+
+type healthCheckResult struct {
+	Name     string `json:"name"`
+	Status   string `json:"status"`
+	Error    string `json:"error,omitempty"`
+	Duration string `json:"duration"`
+}
+
+type healthReport struct {
+	Status string              `json:"status"`
+	Checks []healthCheckResult `json:"checks"`
+}
+
+func newHealthCheck(name string, checker HealthChecker, timeouts ...time.Duration) *healthCheck {
+	timeout := defaultHealthCheckTimeout
+	if len(timeouts) != 0 {
+		timeout = timeouts[0]
+	}
+
+	return &healthCheck{
+		name:    name,
+		timeout: timeout,
+		checker: checker,
+	}
+}
+
+// WithLivenessChecker 注册存活检查, 失败时 /livez 返回 503
+func WithLivenessChecker(name string, checker HealthChecker, timeouts ...time.Duration) ServiceOption {
+	return func(cs *CoresService) {
+		cs.livenessChecks = append(cs.livenessChecks, newHealthCheck(name, checker, timeouts...))
+	}
+}
+
+// WithReadinessChecker 注册就绪检查, 失败时 /readyz 返回 503
+func WithReadinessChecker(name string, checker HealthChecker, timeouts ...time.Duration) ServiceOption {
+	return func(cs *CoresService) {
+		cs.readinessChecks = append(cs.readinessChecks, newHealthCheck(name, checker, timeouts...))
+	}
+}
+
+// WithReadinessDrainDelay 优雅退出时, 在 /readyz 置为失败后等待 delay 再关闭 http 服务,
+// 以便负载均衡(如 Kubernetes)有时间摘除流量
+func WithReadinessDrainDelay(delay time.Duration) ServiceOption {
+	return func(cs *CoresService) {
+		cs.readinessDrainDelay = delay
+	}
+}
+
+func (c *CoresService) markShuttingDown() {
+	c.shuttingDown.Store(true)
+	c.markGrpcShuttingDown()
+}
+
+func (c *CoresService) livezApi(w http.ResponseWriter, r *http.Request) {
+	writeHealthReport(w, runHealthChecks(r.Context(), c.livenessChecks))
+}
+
+func (c *CoresService) readyzApi(w http.ResponseWriter, r *http.Request) {
+	checks := c.readinessChecks
+	if c.shuttingDown.Load() {
+		checks = append([]*healthCheck{
+			newHealthCheck("shutdown", HealthCheckerFunc(func(context.Context) error {
+				return errShuttingDown
+			})),
+		}, checks...)
+	}
+
+	writeHealthReport(w, runHealthChecks(r.Context(), checks))
+}
+
+func runHealthChecks(ctx context.Context, checks []*healthCheck) *healthReport {
+	report := &healthReport{
+		Status: healthStatusOk,
+		Checks: make([]healthCheckResult, len(checks)),
+	}
+
+	var wg sync.WaitGroup
+	for idx, check := range checks {
+		wg.Add(1)
+		go func(idx int, check *healthCheck) {
+			defer wg.Done()
+			report.Checks[idx] = check.run(ctx)
+		}(idx, check)
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		if result.Status != healthStatusOk {
+			report.Status = healthStatusFail
+			break
+		}
+	}
+
+	return report
+}
+
+func (hc *healthCheck) run(ctx context.Context) (result healthCheckResult) {
+	result = healthCheckResult{Name: hc.name, Status: healthStatusOk}
+
+	if hc.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, hc.timeout)
+		defer cancel()
+	}
+
+	start := time.Now()
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if e := recover(); e != nil {
+				done <- errors.Errorf("health check panic: %v", e)
+			}
+		}()
+		done <- hc.checker.HealthCheck(ctx)
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = errors.Wrap(ctx.Err(), "health check timeout")
+	}
+
+	result.Duration = time.Since(start).String()
+	if err != nil {
+		result.Status = healthStatusFail
+		result.Error = err.Error()
+	}
+	return result
+}
+
+func writeHealthReport(w http.ResponseWriter, report *healthReport) {
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	w.Header().Set("Cache-Control", "no-store")
+
+	if report.Status == healthStatusOk {
+		w.WriteHeader(http.StatusOK)
+	} else {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(w).Encode(report)
+}
@@ -0,0 +1,95 @@
+package cores
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func getHealthReport(t *testing.T, handler http.HandlerFunc, url string) (int, healthReport) {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	handler(rec, httptest.NewRequest(http.MethodGet, url, nil))
+	assert.Equal(t, "application/json; charset=utf-8", rec.Header().Get("Content-Type"))
+
+	var report healthReport
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	return rec.Code, report
+}
+
+func TestLivez(t *testing.T) {
+	ok := HealthCheckerFunc(func(context.Context) error { return nil })
+	cs := NewCores(WithLivenessChecker("db", ok))
+
+	code, report := getHealthReport(t, cs.livezApi, livezUrl)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, healthStatusOk, report.Status)
+	require.Len(t, report.Checks, 1)
+	assert.Equal(t, "db", report.Checks[0].Name)
+	assert.Equal(t, healthStatusOk, report.Checks[0].Status)
+	assert.Empty(t, report.Checks[0].Error)
+
+	cs = NewCores(
+		WithLivenessChecker("db", ok),
+		WithLivenessChecker("cache", HealthCheckerFunc(func(context.Context) error { return errors.New("conn refused") })),
+	)
+	code, report = getHealthReport(t, cs.livezApi, livezUrl)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, healthStatusFail, report.Status)
+	require.Len(t, report.Checks, 2)
+	assert.Equal(t, healthStatusOk, report.Checks[0].Status)
+	assert.Equal(t, healthStatusFail, report.Checks[1].Status)
+	assert.Equal(t, "conn refused", report.Checks[1].Error)
+}
+
+func TestHealthCheckTimeout(t *testing.T) {
+	cs := NewCores(WithLivenessChecker("slow", HealthCheckerFunc(func(ctx context.Context) error {
+		<-ctx.Done()
+		time.Sleep(time.Second)
+		return nil
+	}), 20*time.Millisecond))
+
+	start := time.Now()
+	code, report := getHealthReport(t, cs.livezApi, livezUrl)
+	assert.Less(t, time.Since(start), time.Second)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Contains(t, report.Checks[0].Error, "health check timeout")
+}
+
+func TestHealthCheckPanic(t *testing.T) {
+	cs := NewCores(WithReadinessChecker("panic", HealthCheckerFunc(func(context.Context) error {
+		panic("boom")
+	})))
+
+	code, report := getHealthReport(t, cs.readyzApi, readyzUrl)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Equal(t, "health check panic: boom", report.Checks[0].Error)
+}
+
+func TestReadyzShuttingDown(t *testing.T) {
+	cs := NewCores(WithReadinessChecker("db", HealthCheckerFunc(func(context.Context) error { return nil })))
+
+	code, report := getHealthReport(t, cs.readyzApi, readyzUrl)
+	assert.Equal(t, http.StatusOK, code)
+	assert.Len(t, report.Checks, 1)
+
+	// 进入优雅退出后 readyz 失败, livez 不受影响
+	cs.markShuttingDown()
+	code, report = getHealthReport(t, cs.readyzApi, readyzUrl)
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	require.Len(t, report.Checks, 2)
+	assert.Equal(t, "shutdown", report.Checks[0].Name)
+	assert.Equal(t, errShuttingDown.Error(), report.Checks[0].Error)
+	assert.Equal(t, healthStatusOk, report.Checks[1].Status)
+
+	code, _ = getHealthReport(t, cs.livezApi, livezUrl)
+	assert.Equal(t, http.StatusOK, code)
+}
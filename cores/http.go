@@ -16,7 +16,6 @@ import (
 	"time"
 
 	"github.com/miebyte/goutils/internal/innerlog"
-	"github.com/pkg/errors"
 	"github.com/rs/cors"
 )
 
@@ -33,57 +32,113 @@ func WithHttpCORS() ServiceOption {
 
 func WithHttpHandler(pattern string, handler http.Handler) ServiceOption {
 	return func(cs *CoresService) {
-		if !strings.HasPrefix(pattern, "/") {
-			pattern = "/" + pattern
-		}
-
-		if !strings.HasSuffix(pattern, "/") {
-			pattern = pattern + "/"
-		}
-
+		pattern = normalizePattern(pattern)
 		cs.httpPatterns = append(cs.httpPatterns, pattern)
 		innerlog.Logger.Debugf("Registered http endpoint. path=%s\n", pattern)
 		cs.httpMux.Handle(pattern, http.StripPrefix(strings.TrimSuffix(pattern, "/"), handler))
 	}
 }
 
+// normalizePattern 将 pattern 统一为以 / 开头并以 / 结尾的前缀形式
+func normalizePattern(pattern string) string {
+	if !strings.HasPrefix(pattern, "/") {
+		pattern = "/" + pattern
+	}
+
+	if !strings.HasSuffix(pattern, "/") {
+		pattern = pattern + "/"
+	}
+	return pattern
+}
+
 func healthCheckApi(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 	w.Write([]byte("ok"))
 }
 
-func (c *CoresService) listenHttp(lst net.Listener) mountFn {
-	return mountFn{
-		fn: func(ctx context.Context) (err error) {
-			c.httpMux.Handle(healthCheckUrl, http.HandlerFunc(healthCheckApi))
+func (c *CoresService) setupHttpServers() error {
+	adminMux := c.adminMux()
+	adminMux.Handle(healthCheckUrl, http.HandlerFunc(healthCheckApi))
+	adminMux.Handle(livezUrl, http.HandlerFunc(c.livezApi))
+	adminMux.Handle(readyzUrl, http.HandlerFunc(c.readyzApi))
 
-			handler := c.httpHandler
-			if c.httpCors {
-				handler = cors.AllowAll().Handler(handler)
-			}
+	if c.listener != nil {
+		handler := c.httpHandler
+		if c.usePrometheus {
+			handler = recordRoute(handler)
+		}
+
+		if c.httpCors {
+			handler = cors.AllowAll().Handler(handler)
+		}
+
+		handler = c.limitBodySize(handler)
+		handler = c.withRequestTimeout(handler)
+		handler = c.limitConcurrency(handler)
+
+		if c.usePrometheus {
+			handler = c.monitorHttp(handler)
+		}
+
+		if c.tlsEnabled() {
+			handler = withPeerIdentity(handler)
+		}
 
-			if c.usePrometheus {
-				handler = c.monitorHttp(handler)
+		handler = c.grpcHandler(handler)
+
+		c.httpServer = c.newHttpServer(handler)
+		if c.grpc != nil && c.grpc.sharedListener() {
+			// gRPC 依赖 HTTP/2, 未开启 TLS 时通过 h2c 提供
+			c.httpServer.Protocols = new(http.Protocols)
+			c.httpServer.Protocols.SetHTTP1(true)
+			c.httpServer.Protocols.SetHTTP2(true)
+			c.httpServer.Protocols.SetUnencryptedHTTP2(true)
+		}
+
+		if c.tlsEnabled() {
+			tlsConf, err := c.buildTLSConfig()
+			if err != nil {
+				return err
 			}
+			c.httpServer.TLSConfig = tlsConf
+		}
+	}
 
-			c.httpServer = &http.Server{
-				Handler:           handler,
-				ReadTimeout:       c.httpServerConfig.ReadTimeout,
-				ReadHeaderTimeout: c.httpServerConfig.ReadHeaderTimeout,
-				WriteTimeout:      c.httpServerConfig.WriteTimeout,
-				IdleTimeout:       c.httpServerConfig.IdleTimeout,
-				MaxHeaderBytes:    c.httpServerConfig.MaxHeaderBytes,
+	if c.adminHttpMux != nil {
+		c.adminServer = c.newHttpServer(c.adminHttpMux)
+	}
+	return nil
+}
+
+func (c *CoresService) newHttpServer(handler http.Handler) *http.Server {
+	return &http.Server{
+		Handler:           handler,
+		ReadTimeout:       c.httpServerConfig.ReadTimeout,
+		ReadHeaderTimeout: c.httpServerConfig.ReadHeaderTimeout,
+		WriteTimeout:      c.httpServerConfig.WriteTimeout,
+		IdleTimeout:       c.httpServerConfig.IdleTimeout,
+		MaxHeaderBytes:    c.httpServerConfig.MaxHeaderBytes,
+	}
+}
+
+func (c *CoresService) listenHttp(name string, lst net.Listener, server func() *http.Server) mountFn {
+	return mountFn{
+		fn: func(ctx context.Context) (err error) {
+			srv := server()
+			if srv.TLSConfig != nil {
+				err = srv.ServeTLS(lst, "", "")
+			} else {
+				err = srv.Serve(lst)
 			}
-			err = c.httpServer.Serve(lst)
-			if errors.Is(err, net.ErrClosed) || errors.Is(err, http.ErrServerClosed) {
+			if isServerClosed(err) {
 				return nil
 			} else if err != nil {
-				innerlog.Logger.Errorc(ctx, "HttpListener serve error: %v\n", err)
+				innerlog.Logger.Errorc(ctx, "%s serve error: %v\n", name, err)
 				return err
 			}
 			return nil
 		},
-		name: "HttpListener",
+		name: name,
 	}
 }
 
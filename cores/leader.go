@@ -0,0 +1,471 @@
+// File:		leader.go
+// Created by:	Hoven
+// Created on:	2026-10-17
+//
+// This file is part of the Example Project.
+//
+// (c) 2024 Example Corp. All rights reserved.
+
+package cores
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/miebyte/goutils/consulutils"
+	"github.com/miebyte/goutils/internal/innerlog"
+	"github.com/miebyte/goutils/prometheusutils"
+	"github.com/miebyte/goutils/redisutils"
+	"github.com/pkg/errors"
+	"github.com/redis/go-redis/v9"
+)
+
+var (
+	defaultLeaderRetryInterval = time.Second * 5
+	defaultLeaderResignTimeout = time.Second * 5
+	defaultLeaderTTL           = time.Second * 15
+
+	// ErrLeadershipLost 失去 leadership 时 leader worker 的 context 以此为 cause 取消
+	ErrLeadershipLost = errors.New("leadership lost")
+)
+
+// LeaderElector 选主实现, 同一个 key 同一时刻至多有一个实例持有 leadership
+type LeaderElector interface {
+	// Campaign 阻塞直到成为 key 的 leader 或 ctx 结束, 返回的 channel 在失去 leadership 时关闭
+	Campaign(ctx context.Context, key string) (<-chan struct{}, error)
+	// Resign 主动放弃 key 的 leadership
+	Resign(ctx context.Context, key string) error
+}
+
+type leaderWorker struct {
+	*base
+
+	elector       LeaderElector
+	key           string
+	retryInterval time.Duration
+
+	status *workerStatus
+}
+
+type LeaderOption func(*leaderWorker)
+
+// WithLeaderElector 设置选主实现, 默认使用 NewConsulElector(consulutils.GetConsulClient())
+func WithLeaderElector(elector LeaderElector) LeaderOption {
+	return func(w *leaderWorker) {
+		w.elector = elector
+	}
+}
+
+// WithLeaderKey 设置选主使用的 key, 默认为 "cores/leader/<service>/<name>"
+func WithLeaderKey(key string) LeaderOption {
+	return func(w *leaderWorker) {
+		w.key = key
+	}
+}
+
+// WithLeaderRetryInterval 设置竞选失败后重试的间隔, 默认 5s, 实际间隔会附加随机抖动
+func WithLeaderRetryInterval(interval time.Duration) LeaderOption {
+	return func(w *leaderWorker) {
+		if interval > 0 {
+			w.retryInterval = interval
+		}
+	}
+}
+
+// WithLeaderMaxWait 设置退出时的最大等待时间
+func WithLeaderMaxWait(maxWait time.Duration) LeaderOption {
+	return func(w *leaderWorker) {
+		w.maxWait = maxWait
+	}
+}
+
+// WithLeaderWorker 注册一个仅在当前实例持有 leadership 时运行的 worker
+//
+// 失去 leadership 时 fn 的 context 立即取消(cause 为 ErrLeadershipLost), fn 退出后重新参与竞选。
+// fn 在持有 leadership 期间返回时主动放弃 leadership, 返回 nil 表示 worker 结束, 返回错误时与普通 worker 行为一致。
+// 服务退出时同样会主动放弃 leadership, 以便其他实例尽快接管。
+func WithLeaderWorker(name string, fn WorkerFunc, opts ...LeaderOption) ServiceOption {
+	w := &leaderWorker{
+		base: &base{
+			name:    name,
+			maxWait: defaultMaxWait,
+			fn:      fn,
+		},
+		retryInterval: defaultLeaderRetryInterval,
+	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	return func(cs *CoresService) {
+		cs.workers = append(cs.workers, w)
+	}
+}
+
+func (w *leaderWorker) Name() string {
+	return w.name
+}
+
+func (w *leaderWorker) Fn(ctx context.Context) error {
+	return w.run(ctx)
+}
+
+func (w *leaderWorker) run(ctx context.Context) error {
+	for {
+		w.status.setStandby()
+		lost, err := w.elector.Campaign(ctx, w.key)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+
+			delay := jitter(w.retryInterval)
+			innerlog.Logger.Errorc(ctx, "Leader worker(%s) campaign failed: %v, retry in %v", w.name, err, delay)
+			if !sleepContext(ctx, delay) {
+				return nil
+			}
+			continue
+		}
+
+		innerlog.Logger.Infoc(ctx, "Leader worker(%s) acquired leadership. Key=%s", w.name, w.key)
+		prometheusutils.SendWorkerLeaderGauge(w.name, true)
+		w.status.setRunning()
+
+		lostLeadership, err := w.lead(ctx, lost)
+		prometheusutils.SendWorkerLeaderGauge(w.name, false)
+		// 失去 leadership 后同样需要 resign, 以释放 session 续期等后台资源
+		w.resign(ctx)
+
+		if lostLeadership {
+			if ctx.Err() != nil {
+				return nil
+			}
+			if err != nil && !errors.Is(err, context.Canceled) {
+				innerlog.Logger.Warnc(ctx, "Leader worker(%s) exited after leadership lost: %v", w.name, err)
+			}
+			innerlog.Logger.Warnc(ctx, "Leader worker(%s) lost leadership, re-campaigning. Key=%s", w.name, w.key)
+			continue
+		}
+		return err
+	}
+}
+
+// lead 持有 leadership 期间运行 fn, lost 关闭时立即取消 fn 的 context
+func (w *leaderWorker) lead(ctx context.Context, lost <-chan struct{}) (bool, error) {
+	leaderCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+
+	go func() {
+		select {
+		case <-lost:
+			cancel(ErrLeadershipLost)
+		case <-leaderCtx.Done():
+		}
+	}()
+
+	err := w.fn(leaderCtx)
+
+	select {
+	case <-lost:
+		return true, err
+	default:
+		return false, err
+	}
+}
+
+func (w *leaderWorker) resign(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), defaultLeaderResignTimeout)
+	defer cancel()
+
+	if err := w.elector.Resign(ctx, w.key); err != nil {
+		innerlog.Logger.Warnc(ctx, "Leader worker(%s) resign failed: %v", w.name, err)
+		return
+	}
+	innerlog.Logger.Infoc(ctx, "Leader worker(%s) resigned leadership. Key=%s", w.name, w.key)
+}
+
+func (c *CoresService) mountLeaderWorker(worker *leaderWorker) {
+	if worker.elector == nil {
+		worker.elector = NewConsulElector(consulutils.GetConsulClient())
+	}
+	if worker.key == "" {
+		worker.key = fmt.Sprintf("cores/leader/%s/%s", c.serviceName, worker.name)
+	}
+	worker.status = newWorkerStatus(worker.name)
+
+	fn := func(ctx context.Context) error {
+		if err := worker.run(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			innerlog.Logger.Errorc(ctx, "worker: %v run error: %v\n", worker.name, err)
+			return errors.Wrapf(err, "leaderWorker: %v run failed", worker.name)
+		}
+		return nil
+	}
+
+	c.mountFns = append(c.mountFns, mountFn{
+		fn:      fn,
+		maxWait: worker.maxWait,
+		name:    worker.name,
+		status:  worker.status,
+	})
+}
+
+// sleepContext 等待 d 时间, ctx 先结束时返回 false
+func sleepContext(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return false
+	case <-timer.C:
+		return true
+	}
+}
+
+// leaderIdentity 标识当前实例, 写入锁的 value 中便于排查
+func leaderIdentity() string {
+	hostname, _ := os.Hostname()
+	return fmt.Sprintf("%s:%d:%d", hostname, os.Getpid(), time.Now().UnixNano())
+}
+
+// ConsulElector 基于 consul session 与 KV 锁的选主实现
+//
+// session 带 TTL 并由后台自动续期, 实例异常退出时 session 过期, 锁随之释放。
+type ConsulElector struct {
+	client     *consulutils.Client
+	sessionTTL time.Duration
+	lockDelay  time.Duration
+
+	mu    sync.Mutex
+	locks map[string]*consulutils.Lock
+}
+
+type ConsulElectorOption func(*ConsulElector)
+
+// WithConsulSessionTTL 设置 session 的 TTL, 默认 15s
+func WithConsulSessionTTL(ttl time.Duration) ConsulElectorOption {
+	return func(e *ConsulElector) {
+		if ttl > 0 {
+			e.sessionTTL = ttl
+		}
+	}
+}
+
+// WithConsulLockDelay 设置 session 失效后锁不可被再次获取的时间, 默认使用 consul 的 15s
+func WithConsulLockDelay(delay time.Duration) ConsulElectorOption {
+	return func(e *ConsulElector) {
+		e.lockDelay = delay
+	}
+}
+
+func NewConsulElector(client *consulutils.Client, opts ...ConsulElectorOption) *ConsulElector {
+	e := &ConsulElector{
+		client:     client,
+		sessionTTL: defaultLeaderTTL,
+		locks:      make(map[string]*consulutils.Lock),
+	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
+}
+
+func (e *ConsulElector) Campaign(ctx context.Context, key string) (<-chan struct{}, error) {
+	lock, err := e.client.NewLock(key,
+		consulutils.WithLockValue([]byte(leaderIdentity())),
+		consulutils.WithLockSessionName("cores-leader:"+key),
+		consulutils.WithLockSessionTTL(e.sessionTTL),
+		consulutils.WithLockDelay(e.lockDelay),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	lost, err := lock.Lock(ctx)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, err
+	}
+
+	e.mu.Lock()
+	e.locks[key] = lock
+	e.mu.Unlock()
+	return lost, nil
+}
+
+func (e *ConsulElector) Resign(_ context.Context, key string) error {
+	e.mu.Lock()
+	lock, ok := e.locks[key]
+	delete(e.locks, key)
+	e.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+	return lock.Unlock()
+}
+
+const (
+	renewLeaseScript = `
+	if redis.call('get', KEYS[1]) == ARGV[1] then
+		return redis.call('pexpire', KEYS[1], ARGV[2])
+	end
+	return 0`
+
+	releaseLeaseScript = `
+	if redis.call('get', KEYS[1]) == ARGV[1] then
+		return redis.call('del', KEYS[1])
+	end
+	return 0`
+)
+
+var (
+	renewLease   = redis.NewScript(renewLeaseScript)
+	releaseLease = redis.NewScript(releaseLeaseScript)
+)
+
+// RedisElector 基于 redis 租约的选主实现
+//
+// 通过 SET NX PX 获取租约, 持有期间每 ttl/3 续期一次, 续期发现租约已被他人持有,
+// 或连续续期失败超过 ttl 时视为失去 leadership。
+type RedisElector struct {
+	client *redisutils.RedisClient
+	ttl    time.Duration
+
+	mu     sync.Mutex
+	leases map[string]*redisLease
+}
+
+type redisLease struct {
+	value  string
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+// NewRedisElector ttl 为租约时长, <= 0 时默认 15s
+func NewRedisElector(client *redisutils.RedisClient, ttl time.Duration) *RedisElector {
+	if ttl <= 0 {
+		ttl = defaultLeaderTTL
+	}
+	return &RedisElector{
+		client: client,
+		ttl:    ttl,
+		leases: make(map[string]*redisLease),
+	}
+}
+
+func (e *RedisElector) Campaign(ctx context.Context, key string) (<-chan struct{}, error) {
+	value := leaderIdentity()
+	interval := e.ttl / 3
+
+	var acquiredAt time.Time
+	for {
+		acquiredAt = time.Now()
+		ok, err := e.client.SetNX(ctx, key, value, e.ttl).Result()
+		if err != nil {
+			return nil, errors.Wrap(err, "acquire redis lease")
+		}
+		if ok {
+			break
+		}
+
+		if !sleepContext(ctx, interval) {
+			return nil, ctx.Err()
+		}
+	}
+
+	leaseCtx, cancel := context.WithCancel(context.WithoutCancel(ctx))
+	lease := &redisLease{value: value, cancel: cancel, done: make(chan struct{})}
+	lost := make(chan struct{})
+	go func() {
+		defer close(lease.done)
+		e.keepAlive(leaseCtx, key, value, acquiredAt, lost)
+	}()
+
+	e.mu.Lock()
+	e.leases[key] = lease
+	e.mu.Unlock()
+	return lost, nil
+}
+
+type renewResult struct {
+	start time.Time
+	n     int
+	err   error
+}
+
+// keepAlive 每 ttl/3 续期一次租约, 确认失去租约后关闭 lost
+//
+// 租约的有效期从发出请求时开始计算, 距上次续期成功超过 ttl - ttl/3 仍未续期成功时即认为失去租约,
+// 留出一个续期间隔的余量, 保证 leader 的任务在租约过期, 其他节点获取租约之前被取消。
+// 续期请求在单独的 goroutine 中执行, redis 无响应时也不会错过该期限。
+func (e *RedisElector) keepAlive(ctx context.Context, key, value string, renewedAt time.Time, lost chan struct{}) {
+	interval := e.ttl / 3
+	safeTTL := e.ttl - interval
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	expire := time.NewTimer(time.Until(renewedAt.Add(safeTTL)))
+	defer expire.Stop()
+
+	results := make(chan renewResult, 1)
+	pending := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-expire.C:
+			innerlog.Logger.Warnc(ctx, "Redis lease(%s) not renewed within %v, give up leadership", key, safeTTL)
+			close(lost)
+			return
+		case <-ticker.C:
+			if pending {
+				continue
+			}
+			pending = true
+			go func(start time.Time) {
+				n, err := renewLease.Run(ctx, e.client, []string{key}, value, e.ttl.Milliseconds()).Int()
+				results <- renewResult{start: start, n: n, err: err}
+			}(time.Now())
+		case r := <-results:
+			pending = false
+			switch {
+			case ctx.Err() != nil:
+				return
+			case r.err != nil:
+				innerlog.Logger.Warnc(ctx, "Renew redis lease(%s) failed: %v", key, r.err)
+			case r.n == 1:
+				renewedAt = r.start
+				expire.Reset(time.Until(renewedAt.Add(safeTTL)))
+			default:
+				close(lost)
+				return
+			}
+		}
+	}
+}
+
+func (e *RedisElector) Resign(ctx context.Context, key string) error {
+	e.mu.Lock()
+	lease, ok := e.leases[key]
+	delete(e.leases, key)
+	e.mu.Unlock()
+
+	if !ok {
+		return nil
+	}
+
+	lease.cancel()
+	<-lease.done
+
+	if err := releaseLease.Run(ctx, e.client, []string{key}, lease.value).Err(); err != nil {
+		return errors.Wrap(err, "release redis lease")
+	}
+	return nil
+}
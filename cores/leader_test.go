@@ -0,0 +1,137 @@
+package cores
+
+import (
+	"context"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/miebyte/goutils/redisutils"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeElector struct {
+	grant chan chan struct{}
+
+	mu      sync.Mutex
+	resigns int
+}
+
+func (e *fakeElector) Campaign(ctx context.Context, _ string) (<-chan struct{}, error) {
+	select {
+	case lost := <-e.grant:
+		return lost, nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+func (e *fakeElector) Resign(_ context.Context, _ string) error {
+	e.mu.Lock()
+	e.resigns++
+	e.mu.Unlock()
+	return nil
+}
+
+func (e *fakeElector) resignCount() int {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	return e.resigns
+}
+
+func TestLeaderWorkerLostLeadership(t *testing.T) {
+	elector := &fakeElector{grant: make(chan chan struct{})}
+
+	var runs atomic.Int32
+	causes := make(chan error, 2)
+	w := &leaderWorker{
+		base: &base{name: "leader", fn: func(ctx context.Context) error {
+			runs.Add(1)
+			<-ctx.Done()
+			causes <- context.Cause(ctx)
+			return ctx.Err()
+		}},
+		elector:       elector,
+		retryInterval: time.Millisecond,
+		status:        newWorkerStatus("leader"),
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- w.run(ctx)
+	}()
+
+	// 未获得 leadership 前不会运行
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, int32(0), runs.Load())
+	assert.Equal(t, WorkerStandby, w.status.snapshot().State)
+
+	lost := make(chan struct{})
+	elector.grant <- lost
+	require.Eventually(t, func() bool { return runs.Load() == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, WorkerRunning, w.status.snapshot().State)
+
+	close(lost)
+	assert.ErrorIs(t, <-causes, ErrLeadershipLost)
+
+	// 失去 leadership 后重新竞选
+	elector.grant <- make(chan struct{})
+	require.Eventually(t, func() bool { return runs.Load() == 2 }, time.Second, time.Millisecond)
+
+	cancel()
+	assert.ErrorIs(t, <-causes, context.Canceled)
+	assert.ErrorIs(t, <-done, context.Canceled)
+	assert.Equal(t, 2, elector.resignCount())
+}
+
+func TestLeaderWorkerFinished(t *testing.T) {
+	elector := &fakeElector{grant: make(chan chan struct{}, 1)}
+	elector.grant <- make(chan struct{})
+
+	w := &leaderWorker{
+		base:          &base{name: "leader", fn: func(ctx context.Context) error { return nil }},
+		elector:       elector,
+		retryInterval: time.Millisecond,
+	}
+
+	assert.NoError(t, w.run(context.Background()))
+	assert.Equal(t, 1, elector.resignCount())
+}
+
+func TestRedisElectorLosesLeadershipBeforeTTL(t *testing.T) {
+	// redis 无响应时续期请求一直阻塞
+	lst, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer lst.Close()
+	go func() {
+		for {
+			conn, err := lst.Accept()
+			if err != nil {
+				return
+			}
+			defer conn.Close()
+		}
+	}()
+
+	client := &redisutils.RedisClient{Client: redis.NewClient(&redis.Options{Addr: lst.Addr().String(), MaxRetries: -1})}
+	defer client.Close()
+
+	ttl := time.Millisecond * 600
+	e := NewRedisElector(client, ttl)
+
+	acquiredAt := time.Now()
+	lost := make(chan struct{})
+	go e.keepAlive(context.Background(), "leader", "value", acquiredAt, lost)
+
+	select {
+	case <-lost:
+		assert.Less(t, time.Since(acquiredAt), ttl)
+	case <-time.After(ttl):
+		t.Fatal("leadership not given up before the lease expires")
+	}
+}
@@ -0,0 +1,290 @@
+// File:		lifecycle.go
+// Created by:	Hoven
+// Created on:	2026-10-17
+//
+// This file is part of the Example Project.
+//
+// (c) 2024 Example Corp. All rights reserved.
+
+package cores
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/miebyte/goutils/internal/innerlog"
+	"github.com/miebyte/goutils/logging"
+	"github.com/pkg/errors"
+)
+
+const (
+	// HookWorkers 内置 hook: 停止时取消所有 worker 并等待其退出
+	HookWorkers = "cores.workers"
+	// HookHttpServer 内置 hook: 停止时关闭 http 服务, 挂载的 Server 与所有监听, 依赖 HookWorkers
+	HookHttpServer = "cores.http"
+)
+
+var (
+	defaultHookTimeout = time.Second * 10
+)
+
+// LifecycleHook 生命周期钩子
+//
+// 所有 hook 按照 DependsOn 拓扑排序后依次执行 OnStart, 退出时按相反顺序执行 OnStop,
+// 即 hook 总是在其依赖之后启动, 在其依赖之前停止。
+// 未声明依赖内置 hook 的用户 hook 会被视为 HookWorkers 的依赖: 在 worker 启动前启动, 在 worker 全部退出后停止。
+type LifecycleHook struct {
+	Name      string
+	DependsOn []string
+	OnStart   func(ctx context.Context) error
+	OnStop    func(ctx context.Context) error
+	// Timeout 单个 OnStart/OnStop 的超时时间, 为 0 时使用默认值
+	Timeout time.Duration
+
+	started bool
+}
+
+// WithLifecycleHook 注册生命周期钩子, 同名 hook 会被合并
+func WithLifecycleHook(hook LifecycleHook) ServiceOption {
+	return func(cs *CoresService) {
+		if hook.Timeout == 0 {
+			hook.Timeout = defaultHookTimeout
+		}
+		cs.addHook(&hook)
+	}
+}
+
+// WithOnStart 注册启动钩子
+func WithOnStart(name string, fn func(ctx context.Context) error, dependsOn ...string) ServiceOption {
+	return WithLifecycleHook(LifecycleHook{Name: name, DependsOn: dependsOn, OnStart: fn})
+}
+
+// WithOnStop 注册停止钩子
+func WithOnStop(name string, fn func(ctx context.Context) error, dependsOn ...string) ServiceOption {
+	return WithLifecycleHook(LifecycleHook{Name: name, DependsOn: dependsOn, OnStop: fn})
+}
+
+func (c *CoresService) addHook(hook *LifecycleHook) {
+	idx := slices.IndexFunc(c.hooks, func(h *LifecycleHook) bool { return h.Name == hook.Name })
+	if idx < 0 {
+		c.hooks = append(c.hooks, hook)
+		return
+	}
+
+	exists := c.hooks[idx]
+	if hook.OnStart != nil {
+		exists.OnStart = hook.OnStart
+	}
+	if hook.OnStop != nil {
+		exists.OnStop = hook.OnStop
+	}
+	for _, dep := range hook.DependsOn {
+		if !slices.Contains(exists.DependsOn, dep) {
+			exists.DependsOn = append(exists.DependsOn, dep)
+		}
+	}
+	exists.Timeout = max(exists.Timeout, hook.Timeout)
+}
+
+func (c *CoresService) builtinHooks() []*LifecycleHook {
+	return []*LifecycleHook{
+		{
+			Name:   HookWorkers,
+			OnStop: c.stopWorkers,
+		},
+		{
+			Name:      HookHttpServer,
+			DependsOn: []string{HookWorkers},
+			OnStop:    c.stopHttpServer,
+		},
+	}
+}
+
+func (c *CoresService) stopWorkers(ctx context.Context) error {
+	c.cancel()
+
+	done := make(chan struct{})
+	go func() {
+		c.mountWg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		innerlog.Logger.Infoc(ctx, "Graceful stopped workers")
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (c *CoresService) stopHttpServer(ctx context.Context) error {
+	if c.httpServer != nil {
+		_ = c.httpServer.Shutdown(ctx)
+		innerlog.Logger.Infoc(ctx, "Graceful stopped http server")
+	}
+
+	c.stopSharedGrpc(ctx)
+
+	c.shutdownServers(ctx)
+
+	if c.adminServer != nil {
+		_ = c.adminServer.Shutdown(ctx)
+		innerlog.Logger.Infoc(ctx, "Graceful stopped admin http server")
+	}
+
+	if len(c.listeners) != 0 {
+		c.closeListeners()
+		innerlog.Logger.Infoc(ctx, "Graceful stopped listener")
+	}
+	return nil
+}
+
+// sortHooks 按依赖关系对 hook 进行拓扑排序, 无依赖关系的 hook 保持注册顺序
+func sortHooks(hooks []*LifecycleHook) ([]*LifecycleHook, error) {
+	byName := make(map[string]*LifecycleHook, len(hooks))
+	for _, h := range hooks {
+		byName[h.Name] = h
+	}
+
+	inDegree := make(map[string]int, len(hooks))
+	dependents := make(map[string][]string, len(hooks))
+	for _, h := range hooks {
+		for _, dep := range h.DependsOn {
+			if _, ok := byName[dep]; !ok {
+				return nil, errors.Errorf("hook(%s) depends on unknown hook(%s)", h.Name, dep)
+			}
+			inDegree[h.Name]++
+			dependents[dep] = append(dependents[dep], h.Name)
+		}
+	}
+
+	sorted := make([]*LifecycleHook, 0, len(hooks))
+	visited := make(map[string]bool, len(hooks))
+	for len(sorted) < len(hooks) {
+		progressed := false
+		for _, h := range hooks {
+			if visited[h.Name] || inDegree[h.Name] > 0 {
+				continue
+			}
+			visited[h.Name] = true
+			sorted = append(sorted, h)
+			for _, name := range dependents[h.Name] {
+				inDegree[name]--
+			}
+			progressed = true
+		}
+
+		if !progressed {
+			var cycle []string
+			for _, h := range hooks {
+				if !visited[h.Name] {
+					cycle = append(cycle, h.Name)
+				}
+			}
+			return nil, errors.Errorf("lifecycle hooks have circular dependency: %v", cycle)
+		}
+	}
+
+	return sorted, nil
+}
+
+// buildHooks 合并内置 hook 与用户 hook, 并返回排序后的结果
+func (c *CoresService) buildHooks() ([]*LifecycleHook, error) {
+	builtins := c.builtinHooks()
+	hooks := append(slices.Clone(c.hooks), builtins...)
+
+	byName := make(map[string]*LifecycleHook, len(hooks))
+	for _, h := range hooks {
+		byName[h.Name] = h
+	}
+
+	// 用户 hook 若(间接)依赖了内置 hook, 则不能再作为 worker 的依赖, 否则会成环
+	var dependsOnBuiltin func(name string, seen map[string]bool) bool
+	dependsOnBuiltin = func(name string, seen map[string]bool) bool {
+		if seen[name] {
+			return false
+		}
+		seen[name] = true
+
+		h, ok := byName[name]
+		if !ok {
+			return false
+		}
+		for _, dep := range h.DependsOn {
+			if dep == HookWorkers || dep == HookHttpServer || dependsOnBuiltin(dep, seen) {
+				return true
+			}
+		}
+		return false
+	}
+
+	workers := builtins[0]
+	for _, h := range c.hooks {
+		if !dependsOnBuiltin(h.Name, map[string]bool{}) {
+			workers.DependsOn = append(workers.DependsOn, h.Name)
+		}
+	}
+
+	return sortHooks(hooks)
+}
+
+func (h *LifecycleHook) run(ctx context.Context, fn func(ctx context.Context) error) error {
+	if fn == nil {
+		return nil
+	}
+
+	if h.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, h.Timeout)
+		defer cancel()
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		done <- fn(ctx)
+	}()
+
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return errors.Wrapf(ctx.Err(), "hook(%s) timeout", h.Name)
+	}
+}
+
+func (c *CoresService) startHooks() error {
+	for _, hook := range c.sortedHooks {
+		ctx := logging.With(c.ctx, "Hook", hook.Name)
+		if err := hook.run(ctx, hook.OnStart); err != nil {
+			innerlog.Logger.Errorc(ctx, "Start hook(%s) failed: %v", hook.Name, err)
+			return errors.Wrapf(err, "start hook(%s)", hook.Name)
+		}
+		hook.started = true
+	}
+	return nil
+}
+
+// stopHooks 按启动的相反顺序执行已启动 hook 的 OnStop, 只会执行一次
+func (c *CoresService) stopHooks() {
+	c.stopOnce.Do(func() {
+		base := context.WithoutCancel(c.ctx)
+		for _, hook := range slices.Backward(c.sortedHooks) {
+			if !hook.started {
+				continue
+			}
+
+			ctx := logging.With(base, "Hook", hook.Name)
+			start := time.Now()
+			err := hook.run(ctx, hook.OnStop)
+			if err != nil {
+				innerlog.Logger.Errorc(ctx, "Stop hook(%s) failed: %v", hook.Name, err)
+			}
+			c.recordHookShutdown(hook.Name, time.Since(start), err)
+		}
+
+		// 确保 worker 一定会被取消
+		c.cancel()
+	})
+}
@@ -0,0 +1,123 @@
+package cores
+
+import (
+	"context"
+	"net"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func hookNames(hooks []*LifecycleHook) []string {
+	names := make([]string, 0, len(hooks))
+	for _, h := range hooks {
+		names = append(names, h.Name)
+	}
+	return names
+}
+
+func TestSortHooks(t *testing.T) {
+	hooks := []*LifecycleHook{
+		{Name: "cache", DependsOn: []string{"db"}},
+		{Name: "db"},
+		{Name: "metrics"},
+		{Name: "api", DependsOn: []string{"cache", "db"}},
+	}
+
+	sorted, err := sortHooks(hooks)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"db", "metrics", "cache", "api"}, hookNames(sorted))
+}
+
+func TestSortHooksError(t *testing.T) {
+	_, err := sortHooks([]*LifecycleHook{
+		{Name: "a", DependsOn: []string{"b"}},
+		{Name: "b", DependsOn: []string{"c"}},
+		{Name: "c", DependsOn: []string{"a"}},
+		{Name: "d"},
+	})
+	assert.ErrorContains(t, err, "circular dependency: [a b c]")
+
+	_, err = sortHooks([]*LifecycleHook{{Name: "a", DependsOn: []string{"missing"}}})
+	assert.ErrorContains(t, err, "hook(a) depends on unknown hook(missing)")
+}
+
+func TestBuildHooks(t *testing.T) {
+	noop := func(context.Context) error { return nil }
+	cs := NewCores(
+		WithOnStart("db", noop),
+		WithOnStart("cache", noop, "db"),
+		// 依赖内置 hook 的用户 hook 在 http 服务之后启动, 在其之前停止
+		WithOnStart("announce", noop, HookHttpServer),
+		WithOnStop("flush", noop, HookWorkers),
+	)
+
+	hooks, err := cs.buildHooks()
+	require.NoError(t, err)
+
+	names := hookNames(hooks)
+	before := func(a, b string) {
+		t.Helper()
+		assert.Less(t, slices.Index(names, a), slices.Index(names, b), "%s should start before %s: %v", a, b, names)
+	}
+	before("db", "cache")
+	before("cache", HookWorkers)
+	before(HookWorkers, HookHttpServer)
+	before(HookHttpServer, "announce")
+	before(HookWorkers, "flush")
+	assert.Len(t, names, 6)
+}
+
+func TestBuildHooksCycle(t *testing.T) {
+	noop := func(context.Context) error { return nil }
+	cs := NewCores(
+		WithOnStart("a", noop, "b"),
+		WithOnStart("b", noop, "a"),
+	)
+
+	_, err := cs.buildHooks()
+	assert.ErrorContains(t, err, "circular dependency")
+
+	cs = NewCores(WithOnStart("a", noop, "missing"))
+	_, err = cs.buildHooks()
+	assert.ErrorContains(t, err, "unknown hook(missing)")
+}
+
+func TestServeBuildHooksError(t *testing.T) {
+	noop := func(context.Context) error { return nil }
+	cs := NewCores(
+		WithoutSignals(),
+		WithOnStart("a", noop, "b"),
+		WithOnStart("b", noop, "a"),
+	)
+	lst, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	// 启动失败时关闭监听
+	assert.ErrorContains(t, Serve(cs, lst), "buildHooks")
+	_ = lst.(*net.TCPListener).SetDeadline(time.Now().Add(time.Second))
+	_, err = lst.Accept()
+	assert.ErrorIs(t, err, net.ErrClosed)
+}
+
+func TestAddHookMerge(t *testing.T) {
+	noop := func(context.Context) error { return nil }
+	cs := NewCores(
+		WithOnStart("db", noop),
+		WithOnStop("db", noop, "config"),
+		WithOnStart("config", noop),
+	)
+
+	require.Len(t, cs.hooks, 2)
+	db := cs.hooks[0]
+	assert.NotNil(t, db.OnStart)
+	assert.NotNil(t, db.OnStop)
+	assert.Equal(t, []string{"config"}, db.DependsOn)
+
+	hooks, err := cs.buildHooks()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"config", "db", HookWorkers, HookHttpServer}, hookNames(hooks))
+}
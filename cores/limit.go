@@ -0,0 +1,335 @@
+// File:		limit.go
+// Created by:	Hoven
+// Created on:	2026-10-17
+//
+// This file is part of the Example Project.
+//
+// (c) 2024 Example Corp. All rights reserved.
+
+package cores
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/miebyte/goutils/internal/innerlog"
+	"github.com/miebyte/goutils/prometheusutils"
+	"github.com/pkg/errors"
+)
+
+const (
+	shedReasonConcurrency = "concurrency"
+)
+
+var (
+	defaultLimiterMinLimit = 8
+	defaultLimiterWindow   = time.Second
+	limiterBackoffRatio    = 0.9
+	limiterSmoothing       = 0.2
+)
+
+// WithHttpTimeout 设置请求处理超时, 超时后请求 context 会被取消并返回 503(可通过 WithHttpTimeoutStatus 修改)
+// patterns 为空时作用于所有请求, 否则仅作用于 WithHttpHandler 注册的对应 pattern, 且优先于全局超时。
+// 设置了超时的请求响应会先写入缓冲区, 因此不适用于流式响应或 websocket。
+func WithHttpTimeout(timeout time.Duration, patterns ...string) ServiceOption {
+	return func(cs *CoresService) {
+		if len(patterns) == 0 {
+			cs.httpTimeout = timeout
+			return
+		}
+
+		if cs.httpTimeouts == nil {
+			cs.httpTimeouts = make(map[string]time.Duration)
+		}
+		for _, pattern := range patterns {
+			cs.httpTimeouts[normalizePattern(pattern)] = timeout
+		}
+	}
+}
+
+// WithHttpTimeoutStatus 设置请求超时时返回的状态码, 通常为 503 或 504
+func WithHttpTimeoutStatus(code int) ServiceOption {
+	return func(cs *CoresService) {
+		cs.httpTimeoutCode = code
+	}
+}
+
+// WithHttpMaxBodySize 限制请求体大小, 超过时返回 413
+func WithHttpMaxBodySize(size int64) ServiceOption {
+	return func(cs *CoresService) {
+		cs.httpMaxBodySize = size
+	}
+}
+
+type LimiterOption func(*concurrencyLimiter)
+
+// WithLimiterMinLimit 设置自适应调整时并发上限的最小值
+func WithLimiterMinLimit(minLimit int) LimiterOption {
+	return func(l *concurrencyLimiter) {
+		if minLimit > 0 {
+			l.minLimit = minLimit
+		}
+	}
+}
+
+// WithLimiterLatency 设置延迟阈值, 请求延迟(滑动平均)超过阈值时收缩并发上限, 恢复后逐步放开
+// 未设置时并发上限固定为 maxInFlight
+func WithLimiterLatency(threshold time.Duration) LimiterOption {
+	return func(l *concurrencyLimiter) {
+		l.latencyThreshold = threshold
+	}
+}
+
+// WithLimiterWindow 设置两次收缩并发上限的最小间隔, 默认 1s
+func WithLimiterWindow(window time.Duration) LimiterOption {
+	return func(l *concurrencyLimiter) {
+		if window > 0 {
+			l.window = window
+		}
+	}
+}
+
+// WithHttpConcurrencyLimit 开启自适应并发限制, 处理中的请求数达到上限时直接返回 429
+func WithHttpConcurrencyLimit(maxInFlight int, opts ...LimiterOption) ServiceOption {
+	l := &concurrencyLimiter{
+		maxLimit: maxInFlight,
+		minLimit: min(defaultLimiterMinLimit, maxInFlight),
+		window:   defaultLimiterWindow,
+	}
+	for _, opt := range opts {
+		opt(l)
+	}
+	l.minLimit = min(l.minLimit, l.maxLimit)
+	l.limit = float64(l.maxLimit)
+
+	return func(cs *CoresService) {
+		if maxInFlight <= 0 {
+			innerlog.Logger.Warnf("Invalid http concurrency limit: %d, ignored", maxInFlight)
+			return
+		}
+		cs.httpLimiter = l
+	}
+}
+
+func (c *CoresService) limitBodySize(handler http.Handler) http.Handler {
+	if c.httpMaxBodySize <= 0 {
+		return handler
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > c.httpMaxBodySize {
+			http.Error(w, http.StatusText(http.StatusRequestEntityTooLarge), http.StatusRequestEntityTooLarge)
+			return
+		}
+
+		if r.Body != nil && r.Body != http.NoBody {
+			r.Body = http.MaxBytesReader(w, r.Body, c.httpMaxBodySize)
+		}
+		handler.ServeHTTP(w, r)
+	})
+}
+
+// requestTimeout 返回请求匹配的 pattern 及其对应的超时时间
+func (c *CoresService) requestTimeout(r *http.Request) (time.Duration, string) {
+	_, pattern := c.httpMux.Handler(r)
+	if timeout, ok := c.httpTimeouts[pattern]; ok && pattern != "" {
+		return timeout, pattern
+	}
+	return c.httpTimeout, pattern
+}
+
+func (c *CoresService) withRequestTimeout(handler http.Handler) http.Handler {
+	if c.httpTimeout <= 0 && len(c.httpTimeouts) == 0 {
+		return handler
+	}
+
+	code := c.httpTimeoutCode
+	if code == 0 {
+		code = http.StatusServiceUnavailable
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		timeout, pattern := c.requestTimeout(r)
+		if timeout <= 0 || isAdminUrl(r.URL.Path) {
+			handler.ServeHTTP(w, r)
+			return
+		}
+		// 超时返回时 handler 可能还未执行完, 先按 mux pattern 记录路由, 避免被记为未匹配
+		prometheusutils.SetRoute(r.Context(), routeTemplate(pattern, ""))
+
+		ctx, cancel := context.WithTimeout(r.Context(), timeout)
+		defer cancel()
+		r = r.WithContext(ctx)
+
+		tw := &timeoutWriter{h: make(http.Header), code: http.StatusOK}
+		done := make(chan struct{})
+		panicChan := make(chan any, 1)
+		go func() {
+			defer func() {
+				if p := recover(); p != nil {
+					panicChan <- p
+				}
+			}()
+			handler.ServeHTTP(tw, r)
+			close(done)
+		}()
+
+		select {
+		case p := <-panicChan:
+			panic(p)
+		case <-done:
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+
+			dst := w.Header()
+			for k, vv := range tw.h {
+				dst[k] = vv
+			}
+			w.WriteHeader(tw.code)
+			_, _ = w.Write(tw.buf.Bytes())
+		case <-ctx.Done():
+			tw.mu.Lock()
+			defer tw.mu.Unlock()
+
+			tw.timedOut = true
+			if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+				innerlog.Logger.Warnc(ctx, "Http request timeout. Method=%s Path=%s Timeout=%v", r.Method, r.URL.Path, timeout)
+				http.Error(w, http.StatusText(code), code)
+			}
+		}
+	})
+}
+
+// timeoutWriter 缓冲 handler 的响应, 超时后丢弃 handler 的写入
+type timeoutWriter struct {
+	h    http.Header
+	buf  bytes.Buffer
+	code int
+
+	mu          sync.Mutex
+	timedOut    bool
+	wroteHeader bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.h
+}
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !tw.wroteHeader {
+		tw.writeHeaderLocked(http.StatusOK)
+	}
+	return tw.buf.Write(p)
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+
+	if tw.timedOut || tw.wroteHeader {
+		return
+	}
+	tw.writeHeaderLocked(code)
+}
+
+func (tw *timeoutWriter) writeHeaderLocked(code int) {
+	tw.wroteHeader = true
+	tw.code = code
+}
+
+// concurrencyLimiter 基于 AIMD 的自适应并发限制
+//
+// 请求延迟的滑动平均超过阈值时按比例收缩上限(每个窗口至多一次),
+// 延迟正常且处理中的请求接近上限时每轮约增加 1, 上限始终位于 [minLimit, maxLimit] 区间内。
+type concurrencyLimiter struct {
+	minLimit         int
+	maxLimit         int
+	latencyThreshold time.Duration
+	window           time.Duration
+
+	mu           sync.Mutex
+	limit        float64
+	inFlight     int
+	latency      time.Duration
+	lastDecrease time.Time
+}
+
+func (l *concurrencyLimiter) acquire() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.inFlight >= int(l.limit) {
+		return false
+	}
+	l.inFlight++
+	return true
+}
+
+func (l *concurrencyLimiter) release(latency time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.inFlight--
+	if l.latencyThreshold <= 0 {
+		return
+	}
+
+	if l.latency == 0 {
+		l.latency = latency
+	} else {
+		l.latency = time.Duration(limiterSmoothing*float64(latency) + (1-limiterSmoothing)*float64(l.latency))
+	}
+
+	now := time.Now()
+	switch {
+	case l.latency > l.latencyThreshold:
+		if now.Sub(l.lastDecrease) < l.window {
+			return
+		}
+		l.lastDecrease = now
+		l.limit = max(l.limit*limiterBackoffRatio, float64(l.minLimit))
+	case l.inFlight+1 >= int(l.limit):
+		l.limit = min(l.limit+1/l.limit, float64(l.maxLimit))
+	default:
+		return
+	}
+	prometheusutils.SendAPIConcurrencyLimitGauge(l.limit)
+}
+
+func (c *CoresService) limitConcurrency(handler http.Handler) http.Handler {
+	l := c.httpLimiter
+	if l == nil {
+		return handler
+	}
+	prometheusutils.SendAPIConcurrencyLimitGauge(l.limit)
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if isAdminUrl(r.URL.Path) {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		if !l.acquire() {
+			prometheusutils.SendAPIShedCounter(shedReasonConcurrency)
+			w.Header().Set("Retry-After", strconv.Itoa(max(int(l.window/time.Second), 1)))
+			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+			return
+		}
+
+		start := time.Now()
+		defer func() {
+			l.release(time.Since(start))
+		}()
+		handler.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,108 @@
+package cores
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/miebyte/goutils/prometheusutils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConcurrencyLimiter(t *testing.T) {
+	l := &concurrencyLimiter{minLimit: 2, maxLimit: 4, limit: 4, window: time.Hour, latencyThreshold: 10 * time.Millisecond}
+
+	for range 4 {
+		assert.True(t, l.acquire())
+	}
+	assert.False(t, l.acquire())
+
+	l.release(time.Second)
+	assert.InDelta(t, 3.6, l.limit, 0.001)
+	// 同一窗口内不会重复收缩
+	l.release(time.Second)
+	assert.InDelta(t, 3.6, l.limit, 0.001)
+
+	assert.True(t, l.acquire())
+	assert.False(t, l.acquire())
+}
+
+func TestRequestTimeoutAndBodySize(t *testing.T) {
+	cs := NewCores(
+		WithHttpHandler("/slow", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+		})),
+		WithHttpHandler("/fast", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("X-Fast", "1")
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte("ok"))
+		})),
+		WithHttpTimeout(50*time.Millisecond, "/slow"),
+		WithHttpTimeout(time.Second),
+		WithHttpTimeoutStatus(http.StatusGatewayTimeout),
+		WithHttpMaxBodySize(4),
+	)
+	handler := cs.limitBodySize(cs.withRequestTimeout(cs.httpHandler))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/slow/x", nil))
+	assert.Equal(t, http.StatusGatewayTimeout, rec.Code)
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/fast/x", nil))
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	assert.Equal(t, "1", rec.Header().Get("X-Fast"))
+	assert.Equal(t, "ok", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/fast/x", strings.NewReader("too large")))
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+}
+
+// slowReader 每次读取一个字节前等待 delay
+type slowReader struct {
+	n     int
+	delay time.Duration
+}
+
+func (r *slowReader) Read(p []byte) (int, error) {
+	if r.n == 0 {
+		return 0, io.EOF
+	}
+	time.Sleep(r.delay)
+	r.n--
+	p[0] = 'x'
+	return 1, nil
+}
+
+func TestRequestTimeoutWhileReadingBody(t *testing.T) {
+	finished := make(chan struct{})
+	cs := NewCores(
+		WithPrometheus(),
+		WithHttpHandler("/upload", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			defer close(finished)
+			_, _ = io.Copy(io.Discard, r.Body)
+		})),
+		WithHttpTimeout(20*time.Millisecond),
+	)
+	handler := cs.monitorHttp(cs.withRequestTimeout(recordRoute(cs.httpHandler)))
+
+	// 超时返回时 handler 仍在读取请求体
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/upload/x", io.NopCloser(&slowReader{n: 20, delay: 5 * time.Millisecond}))
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	<-finished
+
+	// 超时的请求按 mux pattern 记录路由
+	finished = make(chan struct{})
+	ctx := prometheusutils.WithRouteHolder(context.Background())
+	req = httptest.NewRequest(http.MethodPost, "/upload/x", io.NopCloser(&slowReader{n: 20, delay: 5 * time.Millisecond})).WithContext(ctx)
+	cs.withRequestTimeout(recordRoute(cs.httpHandler)).ServeHTTP(httptest.NewRecorder(), req)
+	assert.Equal(t, "/upload/", prometheusutils.RouteFromContext(ctx))
+	<-finished
+}
@@ -0,0 +1,219 @@
+// File:		listener.go
+// Created by:	Hoven
+// Created on:	2026-10-17
+//
+// This file is part of the Example Project.
+//
+// (c) 2024 Example Corp. All rights reserved.
+
+package cores
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+
+	"github.com/miebyte/goutils/internal/innerlog"
+	"github.com/pkg/errors"
+)
+
+const (
+	// DefaultListener cores.Start 创建的监听, 承载业务 http 服务
+	DefaultListener = "default"
+	// AdminListener 管理监听, 配置后 pprof/metrics/健康检查等端点只在该监听上提供
+	AdminListener = "admin"
+)
+
+// Server 可挂载到 CoresService 上的协议服务, 例如 *http.Server 或自定义 tcp 服务
+type Server interface {
+	Serve(lst net.Listener) error
+	Shutdown(ctx context.Context) error
+}
+
+type namedListener struct {
+	name     string
+	addr     string
+	listener net.Listener
+	// localAddr 对外展示的地址, 未指定 host 时替换为 127.0.0.1
+	localAddr string
+}
+
+type mountedServer struct {
+	name     string
+	listener string
+	server   Server
+}
+
+// WithListener 绑定一个命名监听, addr 支持 int 端口或 host:port
+func WithListener[T listenEntry](name string, addr T) ServiceOption {
+	return func(cs *CoresService) {
+		cs.addListener(name, toAddress(addr))
+	}
+}
+
+// WithAdminListener 绑定管理监听, pprof/prometheus/健康检查等端点会路由到该监听
+func WithAdminListener[T listenEntry](addr T) ServiceOption {
+	return WithListener(AdminListener, addr)
+}
+
+// WithServer 将 srv 挂载到名为 listenerName 的监听上, 与 http 服务一起启动并统一优雅退出
+func WithServer(name string, listenerName string, srv Server) ServiceOption {
+	return func(cs *CoresService) {
+		cs.servers = append(cs.servers, &mountedServer{
+			name:     name,
+			listener: listenerName,
+			server:   srv,
+		})
+	}
+}
+
+func toAddress[T listenEntry](addr T) string {
+	switch v := any(addr).(type) {
+	case int:
+		return ":" + strconv.Itoa(v)
+	case string:
+		return v
+	default:
+		innerlog.Logger.Errorf("unsupport address type: %T", v)
+	}
+	return ""
+}
+
+func (c *CoresService) addListener(name, addr string) {
+	if nl := c.getListener(name); nl != nil {
+		nl.addr = addr
+		return
+	}
+	c.listeners = append(c.listeners, &namedListener{name: name, addr: addr})
+}
+
+func (c *CoresService) getListener(name string) *namedListener {
+	for _, nl := range c.listeners {
+		if nl.name == name {
+			return nl
+		}
+	}
+	return nil
+}
+
+func (c *CoresService) openListeners() error {
+	inherited := inheritedListeners()
+	if inherited != nil {
+		_ = os.Unsetenv(INHERIT_LISTENERS_ENV)
+	}
+	// 新版本移除的监听没有对应的命名监听, 关闭以免一直占用端口
+	defer func() {
+		for name, fd := range inherited {
+			if f := os.NewFile(uintptr(fd), name); f != nil {
+				_ = f.Close()
+			}
+			innerlog.Logger.Infof("Closed inherited listener(%s) which is not declared", name)
+		}
+	}()
+
+	for _, nl := range c.listeners {
+		if nl.listener != nil {
+			continue
+		}
+
+		if fd, ok := inherited[nl.name]; ok {
+			delete(inherited, nl.name)
+			lst, err := inheritListener(nl.name, fd)
+			if err == nil {
+				innerlog.Logger.Infof("Inherited listener(%s) from parent process. Addr=%v", nl.name, lst.Addr())
+				nl.setListener(lst)
+				continue
+			}
+			innerlog.Logger.Warnf("Inherit listener(%s) failed, fallback to listen: %v", nl.name, err)
+		}
+
+		lst, err := net.Listen("tcp", nl.addr)
+		if err != nil {
+			c.closeListeners()
+			return errors.Wrapf(err, "failed to start listener(%s)", nl.name)
+		}
+		nl.setListener(lst)
+	}
+
+	if nl := c.getListener(DefaultListener); nl != nil {
+		c.listener = nl.listener
+		c.listenAddr = nl.localAddr
+	}
+	return nil
+}
+
+func (nl *namedListener) setListener(lst net.Listener) {
+	nl.listener = lst
+	nl.localAddr = lst.Addr().String()
+
+	lstAddr, ok := lst.Addr().(*net.TCPAddr)
+	if ok && lstAddr.IP.IsUnspecified() {
+		nl.localAddr = fmt.Sprintf("127.0.0.1:%v", lstAddr.Port)
+	}
+}
+
+func (c *CoresService) closeListeners() {
+	for _, nl := range c.listeners {
+		if nl.listener != nil {
+			_ = nl.listener.Close()
+		}
+	}
+}
+
+// adminMux 返回承载管理端点的 mux, 未配置管理监听时与业务 mux 相同
+func (c *CoresService) adminMux() *http.ServeMux {
+	if c.adminHttpMux != nil {
+		return c.adminHttpMux
+	}
+	return c.httpMux
+}
+
+// adminAddr 返回管理端点所在的地址
+func (c *CoresService) adminAddr() string {
+	if nl := c.getListener(AdminListener); nl != nil && nl.listener != nil {
+		return nl.localAddr
+	}
+	return c.listenAddr
+}
+
+func (c *CoresService) mountServers() error {
+	for _, ms := range c.servers {
+		nl := c.getListener(ms.listener)
+		if nl == nil || nl.listener == nil {
+			return errors.Errorf("server(%s) listener(%s) not found", ms.name, ms.listener)
+		}
+
+		srv, lst := ms.server, nl.listener
+		c.mountFns = append(c.mountFns, mountFn{
+			name: ms.name,
+			fn: func(ctx context.Context) error {
+				err := srv.Serve(lst)
+				if isServerClosed(err) {
+					return nil
+				} else if err != nil {
+					innerlog.Logger.Errorc(ctx, "Server(%s) serve error: %v\n", ms.name, err)
+					return err
+				}
+				return nil
+			},
+		})
+	}
+	return nil
+}
+
+func (c *CoresService) shutdownServers(ctx context.Context) {
+	for _, ms := range c.servers {
+		if err := ms.server.Shutdown(ctx); err != nil && !isServerClosed(err) {
+			innerlog.Logger.Errorc(ctx, "Graceful stop server(%s) error: %v", ms.name, err)
+			continue
+		}
+		innerlog.Logger.Infoc(ctx, "Graceful stopped server(%s)", ms.name)
+	}
+}
+
+func isServerClosed(err error) bool {
+	return errors.Is(err, net.ErrClosed) || errors.Is(err, http.ErrServerClosed)
+}
@@ -0,0 +1,86 @@
+package cores_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/miebyte/goutils/cores"
+	"github.com/miebyte/goutils/cores/corestest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recordServer 记录 Shutdown 是否被调用的 http.Server
+type recordServer struct {
+	*http.Server
+	shutdown atomic.Bool
+}
+
+func (s *recordServer) Shutdown(ctx context.Context) error {
+	s.shutdown.Store(true)
+	return s.Server.Shutdown(ctx)
+}
+
+func httpGet(t *testing.T, url string) (int, string) {
+	t.Helper()
+
+	resp, err := http.Get(url)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(body)
+}
+
+func TestNamedListeners(t *testing.T) {
+	extra := &recordServer{Server: &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("extra"))
+	})}}
+
+	s := corestest.Start(t,
+		cores.WithAdminListener("127.0.0.1:0"),
+		cores.WithListener("extra", "127.0.0.1:0"),
+		cores.WithServer("extra-server", "extra", extra),
+		cores.WithHttpHandler("/hello", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("world"))
+		})),
+	)
+
+	extraAddr := s.ListenerAddr("extra")
+	require.NotEmpty(t, extraAddr)
+	assert.Empty(t, s.ListenerAddr("unknown"))
+	assert.NotEqual(t, s.URL, s.AdminURL)
+
+	code, body := httpGet(t, s.URL+"/hello/")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "world", body)
+
+	// 管理端点只在管理监听上提供
+	code, _ = httpGet(t, s.AdminURL+"/readyz")
+	assert.Equal(t, http.StatusOK, code)
+	code, _ = httpGet(t, s.URL+"/readyz")
+	assert.Equal(t, http.StatusNotFound, code)
+	code, _ = httpGet(t, s.AdminURL+"/hello/")
+	assert.Equal(t, http.StatusNotFound, code)
+
+	code, body = httpGet(t, "http://"+extraAddr+"/")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, "extra", body)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	require.NoError(t, s.Shutdown(ctx))
+	assert.True(t, extra.shutdown.Load())
+}
+
+func TestServerListenerNotFound(t *testing.T) {
+	cs := cores.NewCores(
+		cores.WithoutSignals(),
+		cores.WithServer("orphan", "missing", &http.Server{}),
+	)
+	err := cores.Start(cs, "127.0.0.1:0")
+	assert.ErrorContains(t, err, "listener(missing) not found")
+}
@@ -31,22 +31,24 @@ func (cs *CoresService) setupPprof() {
 		return
 	}
 
-	if cs.listenAddr == "" {
+	addr := cs.adminAddr()
+	if addr == "" {
 		innerlog.Logger.Warnf("Cores server not start by cores.Start(). Pprof can not be enabled")
 		return
 	}
 
-	cs.httpMux.HandleFunc(pprofUrl, pprof.Index)
-	cs.httpMux.HandleFunc(pprofUrl+"cmdline", pprof.Cmdline)
-	cs.httpMux.HandleFunc(pprofUrl+"profile", pprof.Profile)
-	cs.httpMux.HandleFunc(pprofUrl+"symbol", pprof.Symbol)
-	cs.httpMux.HandleFunc(pprofUrl+"trace", pprof.Trace)
-	cs.httpMux.Handle(pprofUrl+"goroutine", pprof.Handler("goroutine"))
-	cs.httpMux.Handle(pprofUrl+"heap", pprof.Handler("heap"))
-	cs.httpMux.Handle(pprofUrl+"threadcreate", pprof.Handler("threadcreate"))
-	cs.httpMux.Handle(pprofUrl+"block", pprof.Handler("block"))
-
-	_, port, _ := net.SplitHostPort(cs.listenAddr)
+	mux := cs.adminMux()
+	mux.HandleFunc(pprofUrl, pprof.Index)
+	mux.HandleFunc(pprofUrl+"cmdline", pprof.Cmdline)
+	mux.HandleFunc(pprofUrl+"profile", pprof.Profile)
+	mux.HandleFunc(pprofUrl+"symbol", pprof.Symbol)
+	mux.HandleFunc(pprofUrl+"trace", pprof.Trace)
+	mux.Handle(pprofUrl+"goroutine", pprof.Handler("goroutine"))
+	mux.Handle(pprofUrl+"heap", pprof.Handler("heap"))
+	mux.Handle(pprofUrl+"threadcreate", pprof.Handler("threadcreate"))
+	mux.Handle(pprofUrl+"block", pprof.Handler("block"))
+
+	_, port, _ := net.SplitHostPort(addr)
 	target := fmt.Sprintf("localhost:%s", port)
 
 	innerlog.Logger.Debugf("Pprof enabled. URL=%s", fmt.Sprintf("http://%s%s", target, pprofUrl))
@@ -2,9 +2,11 @@ package cores
 
 import (
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/miebyte/goutils/internal/innerlog"
@@ -27,55 +29,117 @@ func (c *CoresService) setupPrometheus() {
 		return
 	}
 
-	if c.listenAddr == "" {
+	addr := c.adminAddr()
+	if addr == "" {
 		innerlog.Logger.Warnf("Cores server not start by cores.Start(). Prometheus can not be enabled")
 		c.usePrometheus = false
 		return
 	}
 
-	c.httpMux.Handle(metricsUrl, prometheusutils.PrometheusHttpHandler())
+	c.adminMux().Handle(metricsUrl, prometheusutils.PrometheusHttpHandler())
 
-	_, port, _ := net.SplitHostPort(c.listenAddr)
+	_, port, _ := net.SplitHostPort(addr)
 	target := fmt.Sprintf("localhost:%s", port)
 	innerlog.Logger.Debugf("Prometheus enabled. URL=%s", fmt.Sprintf("http://%s%s", target, metricsUrl))
 }
 
+var (
+	adminUrls = []string{metricsUrl, pprofUrl, debugUrl, healthCheckUrl, livezUrl, readyzUrl}
+)
+
+// isAdminUrl 判断是否为管理端点, 管理端点不参与接口监控与限流
+func isAdminUrl(path string) bool {
+	for _, adminUrl := range adminUrls {
+		if strings.HasPrefix(path, adminUrl) {
+			return true
+		}
+	}
+	return false
+}
+
 func (c *CoresService) monitorHttp(handler http.Handler) http.Handler {
 	if !c.usePrometheus {
 		return handler
 	}
 
-	ignoreUrls := []string{metricsUrl, pprofUrl, healthCheckUrl}
-	checkIgnoreUrl := func(path string) bool {
-		for _, ignoreUrl := range ignoreUrls {
-			if strings.HasPrefix(path, ignoreUrl) {
-				return true
-			}
-		}
-		return false
-	}
-
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		path := r.URL.Path
-		if checkIgnoreUrl(path) {
+		if isAdminUrl(path) {
 			handler.ServeHTTP(w, r)
 			return
 		}
 
 		prometheusutils.SendAPICounter()
+		prometheusutils.IncAPIInFlightGauge()
+		defer prometheusutils.DecAPIInFlightGauge()
+
+		ctx := prometheusutils.WithRouteHolder(r.Context())
+		r = r.WithContext(ctx)
+
+		var body *countingReadCloser
+		if r.Body != nil && r.Body != http.NoBody {
+			body = &countingReadCloser{ReadCloser: r.Body}
+			r.Body = body
+		}
 
 		start := time.Now()
 		sw := NewStatusCodeResponseWriter(w)
 		handler.ServeHTTP(sw, r)
 		duration := time.Since(start).Milliseconds()
 
-		prometheusutils.SendAPIHistogram(r.URL.Path, float64(duration), sw.StatusCode)
+		route := prometheusutils.RouteFromContext(ctx)
+		prometheusutils.SendAPIHistogram(route, float64(duration), sw.StatusCode)
+
+		requestSize := max(r.ContentLength, 0)
+		if body != nil {
+			requestSize = max(requestSize, body.n.Load())
+		}
+		prometheusutils.SendAPIRequestSizeHistogram(route, float64(requestSize))
+		prometheusutils.SendAPIResponseSizeHistogram(route, float64(sw.Size))
+	})
+}
+
+// recordRoute 在 mux 完成路由后将匹配到的 pattern 写入路由模板容器
+// 若下游(如 gin)已回填了更精确的路由, 则与 pattern 拼接为完整的路由模板
+func recordRoute(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		handler.ServeHTTP(w, r)
+		prometheusutils.SetRoute(r.Context(), routeTemplate(r.Pattern, prometheusutils.RouteFromContext(r.Context())))
 	})
 }
 
+// routeTemplate 拼接 mux pattern 与下游框架的路由, pattern 中的 method 与 host 部分会被忽略
+func routeTemplate(pattern, subRoute string) string {
+	if _, path, ok := strings.Cut(pattern, " "); ok {
+		pattern = path
+	}
+	if idx := strings.Index(pattern, "/"); idx > 0 {
+		pattern = pattern[idx:]
+	}
+
+	if subRoute == "" {
+		return pattern
+	}
+	// WithHttpHandler 注册的 handler 会去除 pattern 前缀, 下游路由是相对路径
+	return strings.TrimSuffix(pattern, "/") + subRoute
+}
+
+// countingReadCloser 统计读取的字节数, 请求超时后 handler 可能仍在读取, 因此使用原子计数
+type countingReadCloser struct {
+	io.ReadCloser
+	n atomic.Int64
+}
+
+func (c *countingReadCloser) Read(p []byte) (int, error) {
+	n, err := c.ReadCloser.Read(p)
+	c.n.Add(int64(n))
+	return n, err
+}
+
 type StatusCodeResponseWriter struct {
 	http.ResponseWriter
 	StatusCode int
+	Size       int64
 }
 
 func NewStatusCodeResponseWriter(w http.ResponseWriter) *StatusCodeResponseWriter {
@@ -89,3 +153,14 @@ func (c *StatusCodeResponseWriter) WriteHeader(statusCode int) {
 	c.StatusCode = statusCode
 	c.ResponseWriter.WriteHeader(statusCode)
 }
+
+func (c *StatusCodeResponseWriter) Write(b []byte) (int, error) {
+	n, err := c.ResponseWriter.Write(b)
+	c.Size += int64(n)
+	return n, err
+}
+
+// Unwrap 供 http.ResponseController 访问底层 ResponseWriter
+func (c *StatusCodeResponseWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
@@ -0,0 +1,26 @@
+package cores
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRouteTemplate(t *testing.T) {
+	tests := []struct {
+		pattern  string
+		subRoute string
+		expected string
+	}{
+		{pattern: "/api/", subRoute: "", expected: "/api/"},
+		{pattern: "/api/", subRoute: "/users/:id", expected: "/api/users/:id"},
+		{pattern: "GET /users/{id}", subRoute: "", expected: "/users/{id}"},
+		{pattern: "example.com/api/", subRoute: "/ping", expected: "/api/ping"},
+		{pattern: "/", subRoute: "/ping", expected: "/ping"},
+		{pattern: "", subRoute: "", expected: ""},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.expected, routeTemplate(tt.pattern, tt.subRoute), tt.pattern)
+	}
+}
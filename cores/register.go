@@ -16,6 +16,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/miebyte/goutils/consulutils"
 	"github.com/miebyte/goutils/discover"
 	"github.com/miebyte/goutils/internal/innerlog"
 	"github.com/pkg/errors"
@@ -43,7 +44,7 @@ func (c *CoresService) registerService() mountFn {
 				}
 			}
 
-			err := discover.GetServiceFinder().RegisterServiceWithTags(c.serviceName, registerAddr, c.tags)
+			err := c.registerToFinder(registerAddr)
 			if err != nil {
 				innerlog.Logger.Errorc(ctx, "Register service(%s) failed. error: %v", c.serviceName, err)
 				return errors.Wrap(err, "registerService")
@@ -54,8 +55,68 @@ func (c *CoresService) registerService() mountFn {
 			// Wait for terminate
 			<-ctx.Done()
 
+			// 热重启时新进程使用相同的服务 ID 注册, 不能注销
+			if c.handedOff.Load() {
+				innerlog.Logger.Infoc(ctx, "Listeners handed off to new process, skip deregister service(%s)", c.serviceName)
+				return nil
+			}
+
 			discover.GetServiceFinder().Close()
 			return nil
 		},
 	}
 }
+
+// WithRegisterOptions 设置注册到 consul 时的参数, 如检查方式, meta, 权重等, 同时开启服务注册
+// 默认使用 TCP 检查, 并携带服务的 tags
+func WithRegisterOptions(opts ...consulutils.RegisterOption) ServiceOption {
+	return func(cs *CoresService) {
+		cs.needRegister = true
+		cs.registerOpts = append(cs.registerOpts, opts...)
+	}
+}
+
+// WithRegisterReadyzCheck 注册到 consul 时使用 HTTP 检查管理端点的 /readyz, 同时开启服务注册
+// 需保证 consul agent 可以通过 HTTP 访问到服务, 开启 TLS 且未配置管理监听时使用 https 并跳过证书校验
+func WithRegisterReadyzCheck() ServiceOption {
+	return func(cs *CoresService) {
+		cs.needRegister = true
+		cs.registerReadyz = true
+	}
+}
+
+type optionsRegister interface {
+	RegisterServiceWithOptions(service, address string, opts ...consulutils.RegisterOption) error
+}
+
+func (c *CoresService) registerToFinder(registerAddr string) error {
+	finder := discover.GetServiceFinder()
+	register, ok := finder.(optionsRegister)
+	if !ok {
+		return finder.RegisterServiceWithTags(c.serviceName, registerAddr, c.tags)
+	}
+
+	opts := []consulutils.RegisterOption{consulutils.WithRegisterTags(c.tags...)}
+	if c.registerReadyz {
+		opts = append(opts, c.readyzCheck(registerAddr)...)
+	}
+	opts = append(opts, c.registerOpts...)
+	return register.RegisterServiceWithOptions(c.serviceName, registerAddr, opts...)
+}
+
+// readyzCheck 使用注册地址的 host 与管理端点的端口拼接 /readyz 检查地址
+func (c *CoresService) readyzCheck(registerAddr string) []consulutils.RegisterOption {
+	host, _, _ := net.SplitHostPort(registerAddr)
+	_, port, _ := net.SplitHostPort(c.adminAddr())
+
+	// 未配置管理监听时健康检查与业务共用监听, 开启 TLS 后需使用 https
+	if c.getListener(AdminListener) == nil && c.tlsEnabled() {
+		return []consulutils.RegisterOption{
+			consulutils.WithHTTPCheck(fmt.Sprintf("https://%s/readyz", net.JoinHostPort(host, port))),
+			consulutils.WithCheckTLSSkipVerify(),
+		}
+	}
+	return []consulutils.RegisterOption{
+		consulutils.WithHTTPCheck(fmt.Sprintf("http://%s/readyz", net.JoinHostPort(host, port))),
+	}
+}
@@ -0,0 +1,124 @@
+package cores
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/miebyte/goutils/consulutils"
+	"github.com/miebyte/goutils/discover"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRegisterAgent 返回连接到模拟 agent 的 consul client, 以及获取最近一次注册参数的函数
+func newRegisterAgent(t *testing.T) (*consulutils.Client, func() *api.AgentServiceRegistration) {
+	t.Helper()
+
+	var (
+		mu   sync.Mutex
+		last *api.AgentServiceRegistration
+	)
+	mux := http.NewServeMux()
+	mux.HandleFunc("PUT /v1/agent/service/register", func(w http.ResponseWriter, r *http.Request) {
+		reg := new(api.AgentServiceRegistration)
+		if err := json.NewDecoder(r.Body).Decode(reg); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		mu.Lock()
+		last = reg
+		mu.Unlock()
+	})
+	mux.HandleFunc("PUT /v1/agent/", func(w http.ResponseWriter, r *http.Request) {})
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+
+	config := api.DefaultConfig()
+	config.Address = strings.TrimPrefix(srv.URL, "http://")
+	client, err := api.NewClient(config)
+	require.NoError(t, err)
+
+	return &consulutils.Client{Client: client}, func() *api.AgentServiceRegistration {
+		mu.Lock()
+		defer mu.Unlock()
+		return last
+	}
+}
+
+func setTestFinder(t *testing.T, finder discover.ServiceFinder) {
+	t.Helper()
+
+	old := discover.GetServiceFinder()
+	discover.SetFinder(finder)
+	t.Cleanup(func() {
+		finder.Close()
+		discover.SetFinder(old)
+	})
+}
+
+func TestRegisterWithOptions(t *testing.T) {
+	client, lastRegistered := newRegisterAgent(t)
+	setTestFinder(t, client)
+
+	cs := NewCores(
+		WithRegisterReadyzCheck(),
+		WithRegisterOptions(
+			consulutils.WithRegisterMeta("zone", "a"),
+			consulutils.WithReregisterInterval(0),
+		),
+	)
+	assert.True(t, cs.needRegister)
+	cs.serviceName, cs.tags = "demo", []string{"dev"}
+	cs.listenAddr = "0.0.0.0:8080"
+
+	require.NoError(t, cs.registerToFinder("10.0.0.1:8080"))
+	reg := lastRegistered()
+	require.NotNil(t, reg)
+	assert.Equal(t, "demo", reg.Name)
+	assert.Equal(t, []string{"dev"}, reg.Tags)
+	assert.Equal(t, "a", reg.Meta["zone"])
+	assert.Equal(t, "http://10.0.0.1:8080/readyz", reg.Check.HTTP)
+	assert.False(t, reg.Check.TLSSkipVerify)
+
+	// 开启 TLS 且未配置管理监听时使用 https
+	cs.tlsCertFile = "tls.crt"
+	require.NoError(t, cs.registerToFinder("10.0.0.1:8080"))
+	assert.Equal(t, "https://10.0.0.1:8080/readyz", lastRegistered().Check.HTTP)
+	assert.True(t, lastRegistered().Check.TLSSkipVerify)
+
+	// WithRegisterOptions 的参数在 readyz 检查之后, 可以覆盖检查方式
+	cs = NewCores(
+		WithRegisterReadyzCheck(),
+		WithRegisterOptions(consulutils.WithTCPCheck(), consulutils.WithReregisterInterval(0)),
+	)
+	cs.serviceName, cs.tags = "demo", []string{"dev"}
+	require.NoError(t, cs.registerToFinder("10.0.0.1:8080"))
+	assert.Empty(t, lastRegistered().Check.HTTP)
+	assert.Equal(t, "10.0.0.1:8080", lastRegistered().Check.TCP)
+}
+
+type tagsFinder struct {
+	*discover.DirectFinder
+	tags []string
+}
+
+func (f *tagsFinder) RegisterServiceWithTags(service, address string, tags []string) error {
+	f.tags = tags
+	return nil
+}
+
+func TestRegisterWithoutOptions(t *testing.T) {
+	finder := &tagsFinder{DirectFinder: discover.NewDirectFinder()}
+	setTestFinder(t, finder)
+
+	// finder 不支持注册参数时只携带 tags
+	cs := NewCores(WithRegisterReadyzCheck(), WithRegisterOptions(consulutils.WithRegisterMeta("zone", "a")))
+	cs.serviceName, cs.tags = "demo", []string{"dev", "canary"}
+	require.NoError(t, cs.registerToFinder("10.0.0.1:8080"))
+	assert.Equal(t, []string{"dev", "canary"}, finder.tags)
+}
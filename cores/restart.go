@@ -0,0 +1,228 @@
+// File:		restart.go
+// Created by:	Hoven
+// Created on:	2026-10-17
+//
+// This file is part of the Example Project.
+//
+// (c) 2024 Example Corp. All rights reserved.
+
+package cores
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/miebyte/goutils/internal/innerlog"
+	"github.com/pkg/errors"
+)
+
+const (
+	// INHERIT_LISTENERS_ENV 热重启时传递给子进程的监听描述, 格式为 name:fd,name:fd
+	INHERIT_LISTENERS_ENV = "GOUTILS_INHERIT_LISTENERS"
+	// RESTART_READY_FD_ENV 热重启时传递给子进程的就绪通知管道 fd, 子进程启动完成后写入一个字节
+	RESTART_READY_FD_ENV = "GOUTILS_RESTART_READY_FD"
+)
+
+var (
+	defaultRestartReadyTimeout = time.Second * 30
+)
+
+// WithGracefulRestart 开启热重启: 收到 SIGUSR2 时以相同参数启动新进程并移交所有监听,
+// 新进程完成启动(Started 关闭)并通过管道通知后, 当前进程才按照正常的优雅退出流程排空请求并退出。
+// 新进程在 readyTimeout(默认 30s) 内未就绪或提前退出时会被终止, 当前进程继续提供服务。
+// 等待新进程就绪期间收到退出信号时, 终止新进程并按正常流程退出。
+// 仅支持类 Unix 系统。
+func WithGracefulRestart(readyTimeout ...time.Duration) ServiceOption {
+	return func(cs *CoresService) {
+		cs.gracefulRestart = true
+		cs.restartReadyTimeout = defaultRestartReadyTimeout
+		if len(readyTimeout) > 0 && readyTimeout[0] > 0 {
+			cs.restartReadyTimeout = readyTimeout[0]
+		}
+	}
+}
+
+// inheritedListeners 解析父进程通过环境变量传递的监听 fd
+func inheritedListeners() map[string]int {
+	value := os.Getenv(INHERIT_LISTENERS_ENV)
+	if value == "" {
+		return nil
+	}
+
+	ret := make(map[string]int)
+	for _, item := range strings.Split(value, ",") {
+		name, fdStr, ok := strings.Cut(item, ":")
+		if !ok {
+			continue
+		}
+		fd, err := strconv.Atoi(fdStr)
+		if err != nil {
+			innerlog.Logger.Warnf("Invalid inherited listener(%s): %v", item, err)
+			continue
+		}
+		ret[name] = fd
+	}
+	return ret
+}
+
+func inheritListener(name string, fd int) (net.Listener, error) {
+	f := os.NewFile(uintptr(fd), name)
+	if f == nil {
+		return nil, errors.Errorf("invalid inherited listener fd(%d)", fd)
+	}
+	defer f.Close()
+
+	lst, err := net.FileListener(f)
+	if err != nil {
+		return nil, errors.Wrapf(err, "inherit listener(%s)", name)
+	}
+	return lst, nil
+}
+
+// listenerFiles 复制所有监听的 fd, 返回传递给子进程的文件与环境变量
+func (c *CoresService) listenerFiles() ([]*os.File, string, error) {
+	var (
+		files []*os.File
+		envs  []string
+	)
+
+	for _, nl := range c.listeners {
+		fl, ok := nl.listener.(interface{ File() (*os.File, error) })
+		if !ok {
+			continue
+		}
+
+		f, err := fl.File()
+		if err != nil {
+			for _, opened := range files {
+				_ = opened.Close()
+			}
+			return nil, "", errors.Wrapf(err, "dup listener(%s)", nl.name)
+		}
+
+		// 0, 1, 2 为标准输入输出, 额外的文件从 3 开始编号
+		envs = append(envs, fmt.Sprintf("%s:%d", nl.name, 3+len(files)))
+		files = append(files, f)
+	}
+
+	return files, strings.Join(envs, ","), nil
+}
+
+// handoff 启动新进程并移交监听, 等待新进程就绪后返回, 成功后当前进程不再注销服务注册
+// ctx 结束时终止新进程并返回错误
+func (c *CoresService) handoff(ctx context.Context) error {
+	files, inherit, err := c.listenerFiles()
+	if err != nil {
+		return err
+	}
+	defer func() {
+		for _, f := range files {
+			_ = f.Close()
+		}
+	}()
+
+	readyR, readyW, err := os.Pipe()
+	if err != nil {
+		return errors.Wrap(err, "create ready pipe")
+	}
+	defer readyR.Close()
+
+	// 就绪管道的写端作为最后一个额外文件传递给子进程
+	readyFd := 3 + len(files)
+	files = append(files, readyW)
+
+	proc, err := forkExec(files, inherit, readyFd)
+	if err != nil {
+		return err
+	}
+	// 关闭父进程持有的写端, 子进程退出时读端才能读到 EOF
+	_ = readyW.Close()
+
+	// ctx 结束时令等待立即超时
+	stop := context.AfterFunc(ctx, func() {
+		_ = readyR.SetReadDeadline(time.Now())
+	})
+	defer stop()
+
+	if err := waitRestartReady(readyR, c.restartReadyTimeout); err != nil {
+		if ctx.Err() != nil {
+			err = errors.Wrap(ctx.Err(), "aborted")
+		}
+		_ = proc.Kill()
+		go proc.Wait()
+		return errors.Wrapf(err, "new process(%d)", proc.Pid)
+	}
+	_ = proc.Release()
+
+	c.handedOff.Store(true)
+	innerlog.Logger.Infof("Graceful restart: new process(%d) is ready, listeners=%s", proc.Pid, inherit)
+	return nil
+}
+
+// waitRestartReady 等待子进程写入就绪通知
+func waitRestartReady(r *os.File, timeout time.Duration) error {
+	if timeout <= 0 {
+		timeout = defaultRestartReadyTimeout
+	}
+	if err := r.SetReadDeadline(time.Now().Add(timeout)); err != nil {
+		return errors.Wrap(err, "set ready deadline")
+	}
+
+	buf := make([]byte, 1)
+	_, err := r.Read(buf)
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, io.EOF):
+		return errors.New("exited before ready")
+	case errors.Is(err, os.ErrDeadlineExceeded):
+		return errors.Errorf("not ready within %v", timeout)
+	default:
+		return errors.Wrap(err, "wait ready")
+	}
+}
+
+// notifyRestartReady 作为热重启的新进程启动完成后, 通知父进程可以退出
+func notifyRestartReady() {
+	value := os.Getenv(RESTART_READY_FD_ENV)
+	if value == "" {
+		return
+	}
+	_ = os.Unsetenv(RESTART_READY_FD_ENV)
+
+	fd, err := strconv.Atoi(value)
+	if err != nil {
+		innerlog.Logger.Warnf("Invalid restart ready fd(%s): %v", value, err)
+		return
+	}
+
+	f := os.NewFile(uintptr(fd), "restart-ready")
+	if f == nil {
+		innerlog.Logger.Warnf("Invalid restart ready fd(%d)", fd)
+		return
+	}
+	defer f.Close()
+
+	if _, err := f.Write([]byte{1}); err != nil {
+		innerlog.Logger.Warnf("Notify parent process ready failed: %v", err)
+	}
+}
+
+func environWithout(keys ...string) []string {
+	envs := make([]string, 0, len(os.Environ())+len(keys))
+	for _, env := range os.Environ() {
+		name, _, _ := strings.Cut(env, "=")
+		if slices.Contains(keys, name) {
+			continue
+		}
+		envs = append(envs, env)
+	}
+	return envs
+}
@@ -0,0 +1,75 @@
+//go:build !windows
+
+package cores
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRestartReadyHandshake(t *testing.T) {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer r.Close()
+
+	// notifyRestartReady 会关闭传入的 fd, 因此传递一个复制的 fd
+	fd, err := syscall.Dup(int(w.Fd()))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	t.Setenv(RESTART_READY_FD_ENV, strconv.Itoa(fd))
+	notifyRestartReady()
+	assert.Empty(t, os.Getenv(RESTART_READY_FD_ENV))
+
+	assert.NoError(t, waitRestartReady(r, time.Second))
+}
+
+func TestRestartReadyFailed(t *testing.T) {
+	r, w, err := os.Pipe()
+	require.NoError(t, err)
+	defer r.Close()
+	defer w.Close()
+
+	assert.ErrorContains(t, waitRestartReady(r, time.Millisecond*50), "not ready within")
+
+	require.NoError(t, w.Close())
+	assert.ErrorContains(t, waitRestartReady(r, time.Second), "exited before ready")
+}
+
+func TestInheritListener(t *testing.T) {
+	lst, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer lst.Close()
+
+	// inheritListener 会关闭传入的 fd, 因此传递一个复制的 fd
+	f, err := lst.(*net.TCPListener).File()
+	require.NoError(t, err)
+	fd, err := syscall.Dup(int(f.Fd()))
+	require.NoError(t, err)
+	// 没有对应命名监听的 fd 会被关闭
+	removed, err := syscall.Dup(int(f.Fd()))
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	t.Setenv(INHERIT_LISTENERS_ENV, "extra:"+strconv.Itoa(fd)+",removed:"+strconv.Itoa(removed))
+
+	cs := NewCores(WithListener("extra", "127.0.0.1:0"), WithListener(DefaultListener, "127.0.0.1:0"))
+	require.NoError(t, cs.openListeners())
+	defer cs.closeListeners()
+
+	// 继承的监听与父进程监听同一个地址, 未继承的监听正常创建
+	assert.Equal(t, lst.Addr().String(), cs.ListenerAddr("extra"))
+	assert.NotEqual(t, lst.Addr().String(), cs.ListenerAddr(DefaultListener))
+	assert.Empty(t, os.Getenv(INHERIT_LISTENERS_ENV))
+
+	dup, err := syscall.Dup(removed)
+	if !assert.ErrorIs(t, err, syscall.EBADF) {
+		_ = syscall.Close(dup)
+	}
+}
@@ -0,0 +1,40 @@
+//go:build !windows
+
+package cores
+
+import (
+	"os"
+	"strconv"
+	"syscall"
+
+	"github.com/pkg/errors"
+)
+
+var restartSignal os.Signal = syscall.SIGUSR2
+
+func forkExec(files []*os.File, inherit string, readyFd int) (*os.Process, error) {
+	path, err := os.Executable()
+	if err != nil {
+		return nil, errors.Wrap(err, "get executable")
+	}
+
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, errors.Wrap(err, "get working directory")
+	}
+
+	env := append(environWithout(INHERIT_LISTENERS_ENV, RESTART_READY_FD_ENV),
+		INHERIT_LISTENERS_ENV+"="+inherit,
+		RESTART_READY_FD_ENV+"="+strconv.Itoa(readyFd),
+	)
+	proc, err := os.StartProcess(path, os.Args, &os.ProcAttr{
+		Dir:   wd,
+		Env:   env,
+		Files: append([]*os.File{os.Stdin, os.Stdout, os.Stderr}, files...),
+		Sys:   &syscall.SysProcAttr{},
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "start new process")
+	}
+	return proc, nil
+}
@@ -0,0 +1,15 @@
+//go:build windows
+
+package cores
+
+import (
+	"os"
+
+	"github.com/pkg/errors"
+)
+
+var restartSignal os.Signal
+
+func forkExec([]*os.File, string, int) (*os.Process, error) {
+	return nil, errors.New("graceful restart is not supported on windows")
+}
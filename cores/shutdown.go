@@ -0,0 +1,229 @@
+// File:		shutdown.go
+// Created by:	Hoven
+// Created on:	2026-10-17
+//
+// This file is part of the Example Project.
+//
+// (c) 2024 Example Corp. All rights reserved.
+
+package cores
+
+import (
+	"context"
+	"os"
+	"time"
+
+	"github.com/miebyte/goutils/internal/innerlog"
+	"github.com/pkg/errors"
+)
+
+const (
+	WorkerExitClean       = "clean"
+	WorkerExitForceClosed = "force_closed"
+	WorkerExitError       = "error"
+
+	shutdownReasonShutdown = "shutdown"
+)
+
+var (
+	// ErrForceClosed worker 在退出时超过 maxWait 仍未结束, 被强制关闭
+	ErrForceClosed = errors.New("worker force closed")
+)
+
+// WorkerShutdown 单个 worker 的退出结果
+type WorkerShutdown struct {
+	Name string `json:"name"`
+	// Result 取值 WorkerExitClean / WorkerExitForceClosed / WorkerExitError
+	Result string `json:"result"`
+	Error  string `json:"error,omitempty"`
+}
+
+// HookShutdown 单个 hook 的 OnStop 执行结果
+type HookShutdown struct {
+	Name     string        `json:"name"`
+	Duration time.Duration `json:"duration"`
+	Error    string        `json:"error,omitempty"`
+}
+
+// ShutdownReport 服务退出报告
+type ShutdownReport struct {
+	// Reason 退出原因, 如 "signal: terminated", "shutdown", "context: context canceled" 或 worker 返回的错误
+	Reason     string           `json:"reason"`
+	StartedAt  time.Time        `json:"started_at"`
+	FinishedAt time.Time        `json:"finished_at"`
+	Error      string           `json:"error,omitempty"`
+	Hooks      []HookShutdown   `json:"hooks"`
+	Workers    []WorkerShutdown `json:"workers"`
+}
+
+// Duration 退出流程的耗时
+func (r *ShutdownReport) Duration() time.Duration {
+	return r.FinishedAt.Sub(r.StartedAt)
+}
+
+// ForceClosed 返回被强制关闭的 worker 名称
+func (r *ShutdownReport) ForceClosed() []string {
+	var names []string
+	for _, w := range r.Workers {
+		if w.Result == WorkerExitForceClosed {
+			names = append(names, w.Name)
+		}
+	}
+	return names
+}
+
+// Clean 所有 worker 均正常退出且所有 hook 均执行成功
+func (r *ShutdownReport) Clean() bool {
+	for _, w := range r.Workers {
+		if w.Result != WorkerExitClean {
+			return false
+		}
+	}
+	for _, h := range r.Hooks {
+		if h.Error != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// WithContext 设置父 context, 父 context 结束时服务按照收到退出信号的流程优雅退出
+// context 中的值(如日志字段)会传递给所有 worker 与 hook
+func WithContext(ctx context.Context) ServiceOption {
+	return func(cs *CoresService) {
+		if ctx != nil {
+			cs.parentCtx = ctx
+		}
+	}
+}
+
+// WithSignals 设置触发优雅退出的信号, 默认为 SIGINT/SIGHUP/SIGTERM/SIGQUIT
+func WithSignals(signals ...os.Signal) ServiceOption {
+	return func(cs *CoresService) {
+		cs.signals = signals
+		cs.disableSignals = len(signals) == 0
+	}
+}
+
+// WithoutSignals 不处理退出信号, 只能通过 Shutdown/Stop 或父 context 退出
+// 适用于嵌入到其他程序或 CLI 子命令中运行的场景
+func WithoutSignals() ServiceOption {
+	return WithSignals()
+}
+
+// Stop 异步触发优雅退出, 可在 worker 内部调用
+func (c *CoresService) Stop() {
+	go func() {
+		_ = c.Shutdown(context.Background())
+	}()
+}
+
+// Shutdown 以编程方式触发优雅退出, 流程与收到退出信号时一致, 并等待服务完全退出
+// 服务尚未启动完成时会先等待启动完成; ctx 结束时不再等待并返回 ctx 的错误。
+// 不要在 worker 中同步调用, 否则会等待自身退出, 应使用 Stop。
+func (c *CoresService) Shutdown(ctx context.Context) error {
+	select {
+	case <-c.started:
+	case <-c.done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	stopped := make(chan struct{})
+	go func() {
+		defer close(stopped)
+		c.shutdown(c.ctx, shutdownReasonShutdown)
+	}()
+
+	for _, ch := range []<-chan struct{}{stopped, c.done} {
+		select {
+		case <-ch:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
+
+// Done 返回服务完全退出后关闭的 channel
+func (c *CoresService) Done() <-chan struct{} {
+	return c.done
+}
+
+// ShutdownReport 返回服务的退出报告, 服务未退出时返回 nil
+func (c *CoresService) ShutdownReport() *ShutdownReport {
+	select {
+	case <-c.done:
+		return c.report
+	default:
+		return nil
+	}
+}
+
+// setShutdownReason 记录首次触发退出的原因
+func (c *CoresService) setShutdownReason(reason string) {
+	c.shutdownMu.Lock()
+	defer c.shutdownMu.Unlock()
+
+	if c.shutdownReason == "" {
+		c.shutdownReason = reason
+		c.shutdownAt = time.Now()
+	}
+}
+
+func (c *CoresService) recordHookShutdown(name string, duration time.Duration, err error) {
+	result := HookShutdown{Name: name, Duration: duration}
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	c.shutdownMu.Lock()
+	c.hookResults = append(c.hookResults, result)
+	c.shutdownMu.Unlock()
+}
+
+func (c *CoresService) finishShutdownReport(err error) {
+	if err != nil {
+		c.setShutdownReason(err.Error())
+	}
+	c.setShutdownReason("workers exited")
+
+	c.shutdownMu.Lock()
+	report := &ShutdownReport{
+		Reason:     c.shutdownReason,
+		StartedAt:  c.shutdownAt,
+		FinishedAt: time.Now(),
+		Hooks:      c.hookResults,
+	}
+	c.shutdownMu.Unlock()
+
+	if err != nil {
+		report.Error = err.Error()
+	}
+
+	for _, ws := range c.workerStatuses {
+		status := ws.snapshot()
+		ws.mu.RLock()
+		exitErr := ws.exitErr
+		ws.mu.RUnlock()
+
+		result := WorkerShutdown{Name: status.Name, Result: WorkerExitClean}
+		switch {
+		case status.ForceClosed:
+			result.Result = WorkerExitForceClosed
+			result.Error = exitErr.Error()
+		case exitErr != nil:
+			result.Result = WorkerExitError
+			result.Error = exitErr.Error()
+		}
+		report.Workers = append(report.Workers, result)
+	}
+	c.report = report
+
+	if forceClosed := report.ForceClosed(); len(forceClosed) != 0 {
+		innerlog.Logger.Warnc(c.ctx, "Shutdown finished with force closed workers. Reason=%s Cost=%v Workers=%v", report.Reason, report.Duration(), forceClosed)
+		return
+	}
+	innerlog.Logger.Debugc(c.ctx, "Shutdown finished. Reason=%s Cost=%v Clean=%v", report.Reason, report.Duration(), report.Clean())
+}
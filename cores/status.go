@@ -0,0 +1,137 @@
+// File:		status.go
+// Created by:	Hoven
+// Created on:	2026-10-17
+//
+// This file is part of the Example Project.
+//
+// (c) 2024 Example Corp. All rights reserved.
+
+package cores
+
+import (
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// WorkerState worker 运行状态
+type WorkerState string
+
+const (
+	WorkerPending    WorkerState = "pending"
+	WorkerRunning    WorkerState = "running"
+	WorkerRestarting WorkerState = "restarting"
+	// WorkerStandby leader worker 未持有 leadership, 等待竞选
+	WorkerStandby WorkerState = "standby"
+	WorkerStopped WorkerState = "stopped"
+)
+
+// WorkerStatus worker 运行状态快照
+type WorkerStatus struct {
+	Name      string      `json:"name"`
+	State     WorkerState `json:"state"`
+	StartedAt time.Time   `json:"started_at,omitzero"`
+	StoppedAt time.Time   `json:"stopped_at,omitzero"`
+	Uptime    string      `json:"uptime,omitempty"`
+	Restarts  int         `json:"restarts"`
+	LastError string      `json:"last_error,omitempty"`
+	// ForceClosed 退出时超过 maxWait 仍未结束而被强制关闭
+	ForceClosed bool `json:"force_closed,omitempty"`
+}
+
+type workerStatus struct {
+	mu      sync.RWMutex
+	status  WorkerStatus
+	exitErr error
+}
+
+func newWorkerStatus(name string) *workerStatus {
+	return &workerStatus{status: WorkerStatus{Name: name, State: WorkerPending}}
+}
+
+func (ws *workerStatus) setRunning() {
+	if ws == nil {
+		return
+	}
+
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if ws.status.State == WorkerRestarting {
+		ws.status.Restarts++
+	}
+	ws.status.State = WorkerRunning
+	ws.status.StartedAt = time.Now()
+	ws.status.StoppedAt = time.Time{}
+}
+
+func (ws *workerStatus) setRestarting(err error) {
+	if ws == nil {
+		return
+	}
+
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	ws.status.State = WorkerRestarting
+	ws.status.StoppedAt = time.Now()
+	if err != nil {
+		ws.status.LastError = err.Error()
+	}
+}
+
+func (ws *workerStatus) setStandby() {
+	if ws == nil {
+		return
+	}
+
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	if ws.status.State == WorkerRunning {
+		ws.status.StoppedAt = time.Now()
+	}
+	ws.status.State = WorkerStandby
+}
+
+func (ws *workerStatus) setStopped(err error) {
+	if ws == nil {
+		return
+	}
+
+	ws.mu.Lock()
+	defer ws.mu.Unlock()
+
+	ws.status.State = WorkerStopped
+	ws.status.StoppedAt = time.Now()
+	ws.status.ForceClosed = errors.Is(err, ErrForceClosed)
+	ws.exitErr = err
+	if err != nil {
+		ws.status.LastError = err.Error()
+	}
+}
+
+func (ws *workerStatus) snapshot() WorkerStatus {
+	ws.mu.RLock()
+	defer ws.mu.RUnlock()
+
+	s := ws.status
+	switch {
+	case s.StartedAt.IsZero():
+	case s.State == WorkerRunning:
+		s.Uptime = time.Since(s.StartedAt).Round(time.Millisecond).String()
+	case s.StoppedAt.After(s.StartedAt):
+		s.Uptime = s.StoppedAt.Sub(s.StartedAt).Round(time.Millisecond).String()
+	}
+	return s
+}
+
+// WorkerStatuses 返回所有 worker 的运行状态
+func (c *CoresService) WorkerStatuses() []WorkerStatus {
+	statuses := make([]WorkerStatus, 0, len(c.workerStatuses))
+	for _, ws := range c.workerStatuses {
+		statuses = append(statuses, ws.snapshot())
+	}
+	return statuses
+}
@@ -0,0 +1,296 @@
+// File:		supervisor.go
+// Created by:	Hoven
+// Created on:	2026-10-17
+//
+// This file is part of the Example Project.
+//
+// (c) 2024 Example Corp. All rights reserved.
+
+package cores
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"runtime/debug"
+	"sync"
+	"time"
+
+	"github.com/miebyte/goutils/internal/innerlog"
+	"github.com/miebyte/goutils/prometheusutils"
+	"github.com/pkg/errors"
+)
+
+// RestartPolicy 受监管 worker 的重启策略
+type RestartPolicy int
+
+const (
+	// RestartNever 退出后不再重启, 返回错误时与普通 worker 行为一致
+	RestartNever RestartPolicy = iota
+	// RestartOnFailure 仅在返回错误或 panic 时重启
+	RestartOnFailure
+	// RestartAlways 无论是否返回错误都重启
+	RestartAlways
+)
+
+func (p RestartPolicy) String() string {
+	switch p {
+	case RestartNever:
+		return "never"
+	case RestartOnFailure:
+		return "on-failure"
+	case RestartAlways:
+		return "always"
+	}
+	return "unknown"
+}
+
+const (
+	restartReasonFailure = "failure"
+	restartReasonPanic   = "panic"
+	restartReasonExit    = "exit"
+)
+
+var (
+	defaultRestartPolicy   = RestartOnFailure
+	defaultInitialBackoff  = time.Second
+	defaultMaxBackoff      = time.Minute
+	defaultMaxRestarts     = 10
+	defaultRestartWindow   = time.Minute * 5
+	errMaxRestartsExceeded = errors.New("max restarts exceeded")
+)
+
+type panicError struct {
+	value any
+	stack []byte
+}
+
+func (e *panicError) Error() string {
+	return fmt.Sprintf("panic: %v", e.value)
+}
+
+type supervisedWorker struct {
+	*base
+
+	policy         RestartPolicy
+	initialBackoff time.Duration
+	maxBackoff     time.Duration
+	maxRestarts    int
+	window         time.Duration
+
+	mu         sync.Mutex
+	restarts   []time.Time
+	restarting bool
+
+	status *workerStatus
+}
+
+type SupervisorOption func(*supervisedWorker)
+
+// WithRestartPolicy 设置重启策略, 默认 RestartOnFailure
+func WithRestartPolicy(policy RestartPolicy) SupervisorOption {
+	return func(w *supervisedWorker) {
+		w.policy = policy
+	}
+}
+
+// WithRestartBackoff 设置指数退避的初始与最大间隔, 实际间隔会附加随机抖动
+func WithRestartBackoff(initial, max time.Duration) SupervisorOption {
+	return func(w *supervisedWorker) {
+		if initial > 0 {
+			w.initialBackoff = initial
+		}
+		if max >= initial {
+			w.maxBackoff = max
+		}
+	}
+}
+
+// WithMaxRestarts 设置 window 时间窗口内的最大重启次数, 超过后 worker 以错误退出
+// maxRestarts <= 0 表示不限制
+func WithMaxRestarts(maxRestarts int, window time.Duration) SupervisorOption {
+	return func(w *supervisedWorker) {
+		w.maxRestarts = maxRestarts
+		w.window = window
+	}
+}
+
+// WithSupervisorMaxWait 设置退出时的最大等待时间
+func WithSupervisorMaxWait(maxWait time.Duration) SupervisorOption {
+	return func(w *supervisedWorker) {
+		w.maxWait = maxWait
+	}
+}
+
+// WithSupervisedWorker 注册一个受监管的 worker, 按重启策略在失败或 panic 后自动重启
+func WithSupervisedWorker(name string, fn WorkerFunc, opts ...SupervisorOption) ServiceOption {
+	w := &supervisedWorker{
+		base: &base{
+			name:    name,
+			maxWait: defaultMaxWait,
+			fn:      fn,
+		},
+		policy:         defaultRestartPolicy,
+		initialBackoff: defaultInitialBackoff,
+		maxBackoff:     defaultMaxBackoff,
+		maxRestarts:    defaultMaxRestarts,
+		window:         defaultRestartWindow,
+	}
+
+	for _, opt := range opts {
+		opt(w)
+	}
+
+	return func(cs *CoresService) {
+		cs.workers = append(cs.workers, w)
+	}
+}
+
+func (w *supervisedWorker) Name() string {
+	return w.name
+}
+
+func (w *supervisedWorker) Fn(ctx context.Context) error {
+	return w.supervise(ctx)
+}
+
+// HealthCheck 处于重启退避中的 worker 视为未就绪
+func (w *supervisedWorker) HealthCheck(_ context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	if w.restarting {
+		return errors.Errorf("worker %s is restarting", w.name)
+	}
+	return nil
+}
+
+func (w *supervisedWorker) setRestarting(restarting bool) {
+	w.mu.Lock()
+	w.restarting = restarting
+	w.mu.Unlock()
+}
+
+func (w *supervisedWorker) runOnce(ctx context.Context) (err error) {
+	defer func() {
+		if e := recover(); e != nil {
+			err = &panicError{value: e, stack: debug.Stack()}
+		}
+	}()
+
+	return w.fn(ctx)
+}
+
+func (w *supervisedWorker) supervise(ctx context.Context) error {
+	backoff := w.initialBackoff
+
+	for {
+		start := time.Now()
+		err := w.runOnce(ctx)
+		if ctx.Err() != nil {
+			return err
+		}
+
+		reason := restartReasonExit
+		var pe *panicError
+		if errors.As(err, &pe) {
+			reason = restartReasonPanic
+			prometheusutils.SendWorkerPanicCounter(w.name)
+			innerlog.Logger.Errorc(ctx, "worker: %v panic: %v\n%s", w.name, pe.value, pe.stack)
+		} else if err != nil {
+			reason = restartReasonFailure
+			innerlog.Logger.Errorc(ctx, "worker: %v run error: %v", w.name, err)
+		}
+
+		switch {
+		case w.policy == RestartNever:
+			return err
+		case w.policy == RestartOnFailure && err == nil:
+			return nil
+		}
+
+		if !w.allowRestart(time.Now()) {
+			innerlog.Logger.Errorc(ctx, "worker: %v restarted %d times within %v, giving up", w.name, w.maxRestarts, w.window)
+			if err == nil {
+				return errMaxRestartsExceeded
+			}
+			return errors.Wrap(err, errMaxRestartsExceeded.Error())
+		}
+
+		// 运行时间超过最大退避间隔时认为 worker 已恢复稳定, 重置退避
+		if time.Since(start) > w.maxBackoff {
+			backoff = w.initialBackoff
+		}
+
+		delay := jitter(backoff)
+		prometheusutils.SendWorkerRestartCounter(w.name, reason)
+		innerlog.Logger.Warnc(ctx, "worker: %v stopped (%s), restarting in %v", w.name, reason, delay)
+
+		w.setRestarting(true)
+		w.status.setRestarting(err)
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			w.setRestarting(false)
+			return nil
+		case <-timer.C:
+		}
+		w.setRestarting(false)
+		w.status.setRunning()
+
+		backoff = min(backoff*2, w.maxBackoff)
+	}
+}
+
+func (w *supervisedWorker) allowRestart(now time.Time) bool {
+	if w.maxRestarts <= 0 {
+		return true
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	recent := w.restarts[:0]
+	for _, t := range w.restarts {
+		if w.window <= 0 || now.Sub(t) < w.window {
+			recent = append(recent, t)
+		}
+	}
+	w.restarts = recent
+
+	if len(w.restarts) >= w.maxRestarts {
+		return false
+	}
+
+	w.restarts = append(w.restarts, now)
+	return true
+}
+
+// jitter 在 [d/2, d) 区间内随机取值, 避免多个 worker 同时重启
+func jitter(d time.Duration) time.Duration {
+	if d <= 1 {
+		return d
+	}
+	half := d / 2
+	return half + rand.N(half)
+}
+
+func (c *CoresService) mountSupervisedWorker(worker *supervisedWorker) {
+	worker.status = newWorkerStatus(worker.name)
+	fn := func(ctx context.Context) error {
+		if err := worker.supervise(ctx); err != nil && !errors.Is(err, context.Canceled) {
+			innerlog.Logger.Errorc(ctx, "worker: %v supervise stopped: %v\n", worker.name, err)
+			return errors.Wrapf(err, "supervisedWorker: %v run failed", worker.name)
+		}
+		return nil
+	}
+
+	c.mountFns = append(c.mountFns, mountFn{
+		fn:      fn,
+		maxWait: worker.maxWait,
+		name:    worker.name,
+		status:  worker.status,
+	})
+	c.readinessChecks = append(c.readinessChecks, newHealthCheck(worker.name, worker))
+}
@@ -0,0 +1,148 @@
+package cores
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSupervisedWorker(fn WorkerFunc, opts ...SupervisorOption) *supervisedWorker {
+	cs := NewCores(WithSupervisedWorker("supervised", fn, opts...))
+	w := cs.workers[0].(*supervisedWorker)
+	w.status = newWorkerStatus(w.name)
+	return w
+}
+
+func TestSupervisedWorkerPolicy(t *testing.T) {
+	errBoom := errors.New("boom")
+
+	tests := []struct {
+		name     string
+		policy   RestartPolicy
+		results  []error
+		wantRuns int32
+		wantErr  error
+	}{
+		{name: "never returns error", policy: RestartNever, results: []error{errBoom}, wantRuns: 1, wantErr: errBoom},
+		{name: "never returns nil", policy: RestartNever, results: []error{nil}, wantRuns: 1},
+		{name: "on-failure restarts on error", policy: RestartOnFailure, results: []error{errBoom, errBoom, nil}, wantRuns: 3},
+		{name: "on-failure stops on nil", policy: RestartOnFailure, results: []error{nil}, wantRuns: 1},
+		{name: "always restarts on nil", policy: RestartAlways, results: []error{nil, errBoom, nil}, wantRuns: 3, wantErr: errMaxRestartsExceeded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var runs atomic.Int32
+			w := newTestSupervisedWorker(func(ctx context.Context) error {
+				n := runs.Add(1)
+				return tt.results[n-1]
+			},
+				WithRestartPolicy(tt.policy),
+				WithRestartBackoff(time.Millisecond, time.Millisecond*2),
+				WithMaxRestarts(len(tt.results)-1, time.Minute),
+			)
+
+			err := w.supervise(context.Background())
+			assert.Equal(t, tt.wantRuns, runs.Load())
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestSupervisedWorkerMaxRestarts(t *testing.T) {
+	var runs atomic.Int32
+	w := newTestSupervisedWorker(func(ctx context.Context) error {
+		runs.Add(1)
+		return errors.New("boom")
+	},
+		WithRestartBackoff(time.Millisecond, time.Millisecond),
+		WithMaxRestarts(2, time.Minute),
+	)
+
+	err := w.supervise(context.Background())
+	assert.ErrorContains(t, err, errMaxRestartsExceeded.Error())
+	assert.ErrorContains(t, err, "boom")
+	assert.Equal(t, int32(3), runs.Load())
+}
+
+func TestSupervisedWorkerRestartWindow(t *testing.T) {
+	w := newTestSupervisedWorker(nil, WithMaxRestarts(2, time.Minute))
+
+	now := time.Now()
+	assert.True(t, w.allowRestart(now))
+	assert.True(t, w.allowRestart(now.Add(time.Second)))
+	assert.False(t, w.allowRestart(now.Add(time.Second*2)))
+
+	// restarts older than the window no longer count
+	assert.True(t, w.allowRestart(now.Add(time.Minute)))
+	assert.False(t, w.allowRestart(now.Add(time.Minute+time.Millisecond*500)))
+	assert.True(t, w.allowRestart(now.Add(time.Minute*2+time.Second)))
+
+	unlimited := newTestSupervisedWorker(nil, WithMaxRestarts(0, time.Minute))
+	for i := 0; i < 100; i++ {
+		assert.True(t, unlimited.allowRestart(now))
+	}
+}
+
+func TestSupervisedWorkerRecoverPanic(t *testing.T) {
+	var runs atomic.Int32
+	w := newTestSupervisedWorker(func(ctx context.Context) error {
+		if runs.Add(1) == 1 {
+			panic("boom")
+		}
+		return nil
+	}, WithRestartBackoff(time.Millisecond, time.Millisecond))
+
+	require.NoError(t, w.supervise(context.Background()))
+	assert.Equal(t, int32(2), runs.Load())
+	status := w.status.snapshot()
+	assert.Equal(t, WorkerRunning, status.State)
+	assert.Equal(t, 1, status.Restarts)
+	assert.Equal(t, "panic: boom", status.LastError)
+}
+
+func TestSupervisedWorkerRestarting(t *testing.T) {
+	started := make(chan struct{}, 2)
+	w := newTestSupervisedWorker(func(ctx context.Context) error {
+		started <- struct{}{}
+		return errors.New("boom")
+	}, WithRestartBackoff(time.Hour, time.Hour))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		done <- w.supervise(ctx)
+	}()
+
+	<-started
+	assert.Eventually(t, func() bool {
+		return w.HealthCheck(ctx) != nil
+	}, time.Second, time.Millisecond*10)
+
+	cancel()
+	select {
+	case err := <-done:
+		assert.NoError(t, err)
+	case <-time.After(time.Second):
+		t.Fatal("supervisor not stopped during backoff")
+	}
+	assert.NoError(t, w.HealthCheck(context.Background()))
+}
+
+func TestJitter(t *testing.T) {
+	for i := 0; i < 100; i++ {
+		d := jitter(time.Second)
+		assert.GreaterOrEqual(t, d, time.Second/2)
+		assert.Less(t, d, time.Second)
+	}
+	assert.Equal(t, time.Duration(1), jitter(1))
+}
@@ -0,0 +1,232 @@
+// File:		tls.go
+// Created by:	Hoven
+// Created on:	2026-10-17
+//
+// This file is part of the Example Project.
+//
+// (c) 2024 Example Corp. All rights reserved.
+
+package cores
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/miebyte/goutils/internal/fswatch"
+	"github.com/miebyte/goutils/internal/innerlog"
+	"github.com/pkg/errors"
+)
+
+type peerIdentityKey struct{}
+
+// PeerIdentity 经过校验的客户端证书身份
+type PeerIdentity struct {
+	CommonName     string
+	Organization   []string
+	DNSNames       []string
+	EmailAddresses []string
+	URIs           []string
+	SerialNumber   string
+	Certificate    *x509.Certificate
+}
+
+// WithTLS 使用证书文件提供 HTTPS 服务, 证书文件变更时会自动重新加载
+func WithTLS(certFile, keyFile string) ServiceOption {
+	return func(cs *CoresService) {
+		cs.tlsCertFile = certFile
+		cs.tlsKeyFile = keyFile
+	}
+}
+
+// WithTLSConfig 使用自定义 tls.Config 提供 HTTPS 服务
+// 与 WithTLS 同时使用时, 证书由 WithTLS 指定的文件提供
+func WithTLSConfig(conf *tls.Config) ServiceOption {
+	return func(cs *CoresService) {
+		cs.tlsConfig = conf
+	}
+}
+
+// WithClientCA 开启双向认证, 客户端证书需由 caFiles 中的 CA 签发
+func WithClientCA(caFiles ...string) ServiceOption {
+	return func(cs *CoresService) {
+		cs.tlsClientCAFiles = append(cs.tlsClientCAFiles, caFiles...)
+	}
+}
+
+// PeerIdentityFromContext 获取 mTLS 校验通过的客户端身份
+func PeerIdentityFromContext(ctx context.Context) (*PeerIdentity, bool) {
+	identity, ok := ctx.Value(peerIdentityKey{}).(*PeerIdentity)
+	return identity, ok
+}
+
+func (c *CoresService) tlsEnabled() bool {
+	return c.tlsConfig != nil || c.tlsCertFile != ""
+}
+
+func (c *CoresService) buildTLSConfig() (*tls.Config, error) {
+	conf := &tls.Config{MinVersion: tls.VersionTLS12}
+	if c.tlsConfig != nil {
+		conf = c.tlsConfig.Clone()
+	}
+
+	if c.tlsCertFile != "" {
+		reloader, err := newCertReloader(c.tlsCertFile, c.tlsKeyFile)
+		if err != nil {
+			return nil, err
+		}
+		c.certReloader = reloader
+		conf.Certificates = nil
+		conf.GetCertificate = reloader.GetCertificate
+	}
+
+	if len(c.tlsClientCAFiles) != 0 {
+		pool := x509.NewCertPool()
+		for _, caFile := range c.tlsClientCAFiles {
+			pem, err := os.ReadFile(caFile)
+			if err != nil {
+				return nil, errors.Wrapf(err, "read client ca(%s)", caFile)
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, errors.Errorf("no certificate found in client ca(%s)", caFile)
+			}
+		}
+		conf.ClientCAs = pool
+		conf.ClientAuth = tls.RequireAndVerifyClientCert
+	}
+
+	if len(conf.Certificates) == 0 && conf.GetCertificate == nil && conf.GetConfigForClient == nil {
+		return nil, errors.New("tls enabled but no certificate configured")
+	}
+
+	return conf, nil
+}
+
+// withPeerIdentity 将校验通过的客户端证书身份写入请求上下文
+func withPeerIdentity(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+			handler.ServeHTTP(w, r)
+			return
+		}
+
+		cert := r.TLS.VerifiedChains[0][0]
+		identity := &PeerIdentity{
+			CommonName:     cert.Subject.CommonName,
+			Organization:   cert.Subject.Organization,
+			DNSNames:       cert.DNSNames,
+			EmailAddresses: cert.EmailAddresses,
+			SerialNumber:   cert.SerialNumber.String(),
+			Certificate:    cert,
+		}
+		for _, uri := range cert.URIs {
+			identity.URIs = append(identity.URIs, uri.String())
+		}
+
+		handler.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), peerIdentityKey{}, identity)))
+	})
+}
+
+type certReloader struct {
+	certFile string
+	keyFile  string
+
+	mu   sync.RWMutex
+	cert *tls.Certificate
+}
+
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	cr := &certReloader{
+		certFile: absPath(certFile),
+		keyFile:  absPath(keyFile),
+	}
+	if err := cr.reload(); err != nil {
+		return nil, err
+	}
+	return cr, nil
+}
+
+func absPath(p string) string {
+	if abs, err := filepath.Abs(p); err == nil {
+		return abs
+	}
+	return p
+}
+
+func (cr *certReloader) reload() error {
+	cert, err := tls.LoadX509KeyPair(cr.certFile, cr.keyFile)
+	if err != nil {
+		return errors.Wrap(err, "load tls key pair")
+	}
+
+	cr.mu.Lock()
+	cr.cert = &cert
+	cr.mu.Unlock()
+	return nil
+}
+
+func (cr *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	cr.mu.RLock()
+	defer cr.mu.RUnlock()
+	return cr.cert, nil
+}
+
+// watch 监听证书所在目录, 证书或私钥文件变更时重新加载; 加载失败时保留旧证书
+func (cr *certReloader) watch(ctx context.Context) error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return errors.Wrap(err, "create cert watcher")
+	}
+	defer w.Close()
+
+	dirs := map[string]struct{}{
+		filepath.Dir(cr.certFile): {},
+		filepath.Dir(cr.keyFile):  {},
+	}
+	for dir := range dirs {
+		if err := w.Add(dir); err != nil {
+			return errors.Wrapf(err, "watch cert dir(%s)", dir)
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-w.Events:
+			if !ok {
+				return nil
+			}
+			if !fswatch.FileChanged(ev, cr.certFile, cr.keyFile) {
+				continue
+			}
+
+			if err := cr.reload(); err != nil {
+				innerlog.Logger.Warnc(ctx, "Reload tls certificate failed, keep previous one: %v", err)
+				continue
+			}
+			innerlog.Logger.Infoc(ctx, "Reloaded tls certificate. Cert=%s", cr.certFile)
+		case err, ok := <-w.Errors:
+			if !ok {
+				return nil
+			}
+			innerlog.Logger.Errorc(ctx, "Watch tls certificate error: %v", err)
+		}
+	}
+}
+
+func (c *CoresService) mountCertReloader() {
+	if c.certReloader == nil {
+		return
+	}
+
+	c.mountFns = append(c.mountFns, mountFn{
+		name: "TLSCertReloader",
+		fn:   c.certReloader.watch,
+	})
+}
@@ -0,0 +1,221 @@
+package cores
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testCert struct {
+	cert    *x509.Certificate
+	key     *ecdsa.PrivateKey
+	certPEM []byte
+	keyPEM  []byte
+}
+
+// newTestCert 生成由 parent 签发的证书, parent 为 nil 时生成自签名 CA
+func newTestCert(t *testing.T, cn string, serial int64, parent *testCert) *testCert {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn, Organization: []string{"goutils"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		DNSNames:     []string{cn},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+
+	signer, signerKey := tmpl, key
+	if parent == nil {
+		tmpl.IsCA = true
+		tmpl.BasicConstraintsValid = true
+	} else {
+		signer, signerKey = parent.cert, parent.key
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	return &testCert{
+		cert:    cert,
+		key:     key,
+		certPEM: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		keyPEM:  pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}),
+	}
+}
+
+func (c *testCert) write(t *testing.T, dir string) (string, string) {
+	t.Helper()
+
+	certFile, keyFile := filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key")
+	require.NoError(t, os.WriteFile(certFile, c.certPEM, 0o600))
+	require.NoError(t, os.WriteFile(keyFile, c.keyPEM, 0o600))
+	return certFile, keyFile
+}
+
+func (c *testCert) tlsCertificate(t *testing.T) tls.Certificate {
+	t.Helper()
+
+	cert, err := tls.X509KeyPair(c.certPEM, c.keyPEM)
+	require.NoError(t, err)
+	return cert
+}
+
+func TestTLSClientCA(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, "ca", 1, nil)
+	certFile, keyFile := newTestCert(t, "server", 2, ca).write(t, dir)
+	caFile := filepath.Join(dir, "ca.crt")
+	require.NoError(t, os.WriteFile(caFile, ca.certPEM, 0o600))
+
+	cs := NewCores(
+		WithoutSignals(),
+		WithTLS(certFile, keyFile),
+		WithClientCA(caFile),
+		WithHttpHandler("/whoami", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			identity, ok := PeerIdentityFromContext(r.Context())
+			if !ok {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Write([]byte(identity.CommonName + "/" + identity.SerialNumber))
+		})),
+	)
+	lst, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	done := make(chan error, 1)
+	go func() { done <- Serve(cs, lst) }()
+	<-cs.Started()
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		assert.NoError(t, cs.Shutdown(ctx))
+		<-done
+	})
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.cert)
+	url := "https://" + cs.ListenerAddr(DefaultListener) + "/whoami/"
+	newClient := func(certs ...tls.Certificate) *http.Client {
+		return &http.Client{Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: roots, Certificates: certs},
+		}}
+	}
+
+	resp, err := newClient(newTestCert(t, "client", 3, ca).tlsCertificate(t)).Get(url)
+	require.NoError(t, err)
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "client/3", string(body))
+
+	// 没有客户端证书或证书不是由 CA 签发时握手失败
+	_, err = newClient().Get(url)
+	assert.Error(t, err)
+	other := newTestCert(t, "other-ca", 4, nil)
+	_, err = newClient(newTestCert(t, "client", 5, other).tlsCertificate(t)).Get(url)
+	assert.Error(t, err)
+}
+
+func servingSerial(t *testing.T, cr *certReloader) int64 {
+	t.Helper()
+
+	cert, err := cr.GetCertificate(nil)
+	require.NoError(t, err)
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	require.NoError(t, err)
+	return leaf.SerialNumber.Int64()
+}
+
+func watchCerts(t *testing.T, cr *certReloader) {
+	t.Helper()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert.NoError(t, cr.watch(ctx))
+	}()
+	t.Cleanup(func() {
+		cancel()
+		<-done
+	})
+	// 等待 watcher 注册完成
+	time.Sleep(50 * time.Millisecond)
+}
+
+func TestCertReloaderRewrite(t *testing.T) {
+	dir := t.TempDir()
+	ca := newTestCert(t, "ca", 1, nil)
+	certFile, keyFile := newTestCert(t, "server", 10, ca).write(t, dir)
+
+	cr, err := newCertReloader(certFile, keyFile)
+	require.NoError(t, err)
+	assert.EqualValues(t, 10, servingSerial(t, cr))
+	watchCerts(t, cr)
+
+	newTestCert(t, "server", 11, ca).write(t, dir)
+	assert.Eventually(t, func() bool {
+		return servingSerial(t, cr) == 11
+	}, 2*time.Second, 20*time.Millisecond)
+
+	// 加载失败时保留旧证书
+	require.NoError(t, os.WriteFile(certFile, []byte("broken"), 0o600))
+	time.Sleep(100 * time.Millisecond)
+	assert.EqualValues(t, 11, servingSerial(t, cr))
+}
+
+func TestCertReloaderSymlinkSwap(t *testing.T) {
+	// 模拟 Kubernetes secret 挂载: tls.crt -> ..data/tls.crt, ..data -> ..v1
+	dir := t.TempDir()
+	ca := newTestCert(t, "ca", 1, nil)
+	writeVersion := func(name string, serial int64) {
+		require.NoError(t, os.Mkdir(filepath.Join(dir, name), 0o700))
+		newTestCert(t, "server", serial, ca).write(t, filepath.Join(dir, name))
+	}
+
+	writeVersion("..v1", 20)
+	require.NoError(t, os.Symlink("..v1", filepath.Join(dir, "..data")))
+	for _, name := range []string{"tls.crt", "tls.key"} {
+		require.NoError(t, os.Symlink(filepath.Join("..data", name), filepath.Join(dir, name)))
+	}
+
+	cr, err := newCertReloader(filepath.Join(dir, "tls.crt"), filepath.Join(dir, "tls.key"))
+	require.NoError(t, err)
+	assert.EqualValues(t, 20, servingSerial(t, cr))
+	watchCerts(t, cr)
+
+	writeVersion("..v2", 21)
+	require.NoError(t, os.Symlink("..v2", filepath.Join(dir, "..data_tmp")))
+	require.NoError(t, os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")))
+
+	assert.Eventually(t, func() bool {
+		return servingSerial(t, cr) == 21
+	}, 2*time.Second, 20*time.Millisecond)
+}
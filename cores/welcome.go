@@ -16,17 +16,41 @@ import (
 )
 
 func (c *CoresService) welcome() {
-	if c.listener != nil {
-		innerlog.Logger.Infof("Listening... Addr=%v\n", c.listener.Addr().String())
+	for _, nl := range c.listeners {
+		if nl.listener == nil {
+			continue
+		}
+		if nl.name == DefaultListener {
+			innerlog.Logger.Infof("Listening... Addr=%v\n", nl.listener.Addr().String())
+		} else {
+			innerlog.Logger.Infof("Listening(%s)... Addr=%v\n", nl.name, nl.listener.Addr().String())
+		}
+	}
+
+	for _, ms := range c.servers {
+		innerlog.Logger.Infof("Server(%s) mounted on listener(%s)\n", ms.name, ms.listener)
 	}
 
 	if len(c.httpPatterns) != 0 {
 		_, port, _ := net.SplitHostPort(c.listenAddr)
 		target := fmt.Sprintf("127.0.0.1:%s", port)
 
+		scheme := "http"
+		if c.tlsEnabled() {
+			scheme = "https"
+		}
+
 		for _, httpPattern := range c.httpPatterns {
-			innerlog.Logger.Infof("HttpHandler enabled. URL=%s\n", fmt.Sprintf("http://%s%s", target, httpPattern))
+			innerlog.Logger.Infof("HttpHandler enabled. URL=%s\n", fmt.Sprintf("%s://%s%s", scheme, target, httpPattern))
+		}
+	}
+
+	if services := c.grpcServices(); len(services) != 0 {
+		listener := c.grpc.listener
+		if listener == "" {
+			listener = DefaultListener
 		}
+		innerlog.Logger.Infof("GrpcServer enabled on listener(%s). Services=%v\n", listener, services)
 	}
 
 	if c.serviceName != "" {
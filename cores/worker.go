@@ -96,6 +96,18 @@ func (c *CoresService) wrapWorker() {
 				w.name = GetFuncName(w)
 			}
 			c.mountSimpleWorker(w)
+		case *supervisedWorker:
+			if w.name == "" {
+				w.name = GetFuncName(w.fn)
+			}
+			c.mountSupervisedWorker(w)
+		case *cronWorker:
+			c.mountCronWorker(w)
+		case *leaderWorker:
+			if w.name == "" {
+				w.name = GetFuncName(w.fn)
+			}
+			c.mountLeaderWorker(w)
 		default:
 			innerlog.Logger.Warnc(c.ctx, "Unknown worker type. worker: %v\n", GetFuncName(w))
 		}
@@ -154,7 +166,7 @@ func waitUntilTimeout(ctx context.Context, stop chan error, maxWait time.Duratio
 	select {
 	case <-t1.C:
 		innerlog.Logger.Warnc(ctx, "Force closing worker")
-		return errors.Wrap(ctx.Err(), "Force close")
+		return errors.Wrapf(ErrForceClosed, "after %v", maxWait)
 	case err := <-stop:
 		return errors.Wrap(err, "Stop")
 	}
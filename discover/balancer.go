@@ -0,0 +1,562 @@
+// File:		balancer.go
+// Created by:	Hoven
+// Created on:	2026-10-17
+//
+// This file is part of the Example Project.
+//
+// (c) 2024 Example Corp. All rights reserved.
+
+package discover
+
+import (
+	"context"
+	"hash/crc32"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/miebyte/goutils/internal/innerlog"
+	"github.com/pkg/errors"
+)
+
+// Strategy 负载均衡策略
+type Strategy int
+
+const (
+	// RoundRobin 轮询
+	RoundRobin Strategy = iota
+	// WeightedRandom 按 consul 中注册的权重随机
+	WeightedRandom
+	// LeastOutstanding 在随机的两个节点中选择处理中请求更少的一个
+	LeastOutstanding
+	// ConsistentHash 按 key 一致性哈希, 相同 key 尽量落在同一个节点上
+	ConsistentHash
+)
+
+func (s Strategy) String() string {
+	switch s {
+	case RoundRobin:
+		return "round-robin"
+	case WeightedRandom:
+		return "weighted-random"
+	case LeastOutstanding:
+		return "least-outstanding"
+	case ConsistentHash:
+		return "consistent-hash"
+	}
+	return "unknown"
+}
+
+var (
+	// ErrNoEndpoint 服务没有可用的节点
+	ErrNoEndpoint = errors.New("no available endpoint")
+
+	defaultRefreshInterval     = time.Second * 10
+	defaultEjectionFailures    = 5
+	defaultEjectionTime        = time.Second * 30
+	defaultMaxEjectionTime     = time.Minute * 5
+	defaultMaxEjectionPercent  = 50
+	consistentHashVirtualNodes = 100
+	// balancerIdleTimeout 全局负载均衡器超过该时间未被使用时关闭并移除
+	balancerIdleTimeout = time.Minute * 10
+)
+
+type balancedEndpoint struct {
+	Endpoint
+
+	outstanding atomic.Int64
+
+	// 以下字段由 Balancer.mu 保护
+	failures     int
+	ejections    int
+	ejectedUntil time.Time
+}
+
+type hashNode struct {
+	hash uint32
+	ep   *balancedEndpoint
+}
+
+type ejectionConfig struct {
+	failures   int
+	baseTime   time.Duration
+	maxTime    time.Duration
+	maxPercent int
+}
+
+// Balancer 单个 service/tag 的负载均衡器
+//
+// 节点列表优先通过 Watcher 订阅更新, 当前 ServiceFinder 不支持监听时定期通过 GetAllAddressWithTag 刷新。
+// 调用方通过 Selection.Done 反馈调用结果, 连续失败的节点会被临时摘除。
+type Balancer struct {
+	service         string
+	tag             string
+	strategy        Strategy
+	finder          ServiceFinder
+	refreshInterval time.Duration
+	ejection        ejectionConfig
+
+	once      sync.Once
+	ctx       context.Context
+	cancel    context.CancelFunc
+	watching  bool
+	refreshMu sync.Mutex
+
+	mu          sync.RWMutex
+	endpoints   []*balancedEndpoint
+	ring        []hashNode
+	refreshedAt time.Time
+
+	next     atomic.Uint64
+	lastUsed atomic.Int64
+}
+
+type BalancerOption func(*Balancer)
+
+// WithStrategy 设置负载均衡策略, 默认 RoundRobin
+func WithStrategy(strategy Strategy) BalancerOption {
+	return func(b *Balancer) {
+		b.strategy = strategy
+	}
+}
+
+// WithBalancerFinder 使用指定的 ServiceFinder 获取节点, 默认使用全局的 ServiceFinder
+func WithBalancerFinder(finder ServiceFinder) BalancerOption {
+	return func(b *Balancer) {
+		b.finder = finder
+	}
+}
+
+// WithRefreshInterval 设置 ServiceFinder 不支持监听时刷新节点列表的间隔, 默认 10s
+func WithRefreshInterval(interval time.Duration) BalancerOption {
+	return func(b *Balancer) {
+		if interval > 0 {
+			b.refreshInterval = interval
+		}
+	}
+}
+
+// WithOutlierEjection 设置异常节点摘除策略
+// 节点连续失败 failures 次后摘除 baseTime * 被摘除次数(最长 5m), 同时被摘除的节点不超过 maxPercent%
+// failures <= 0 时关闭摘除
+func WithOutlierEjection(failures int, baseTime time.Duration, maxPercent int) BalancerOption {
+	return func(b *Balancer) {
+		b.ejection.failures = failures
+		if baseTime > 0 {
+			b.ejection.baseTime = baseTime
+		}
+		if maxPercent > 0 && maxPercent <= 100 {
+			b.ejection.maxPercent = maxPercent
+		}
+	}
+}
+
+func NewBalancer(service, tag string, opts ...BalancerOption) *Balancer {
+	b := &Balancer{
+		service:         service,
+		tag:             tag,
+		strategy:        RoundRobin,
+		refreshInterval: defaultRefreshInterval,
+		ejection: ejectionConfig{
+			failures:   defaultEjectionFailures,
+			baseTime:   defaultEjectionTime,
+			maxTime:    defaultMaxEjectionTime,
+			maxPercent: defaultMaxEjectionPercent,
+		},
+	}
+	b.ctx, b.cancel = context.WithCancel(context.Background())
+	b.lastUsed.Store(time.Now().UnixNano())
+
+	for _, opt := range opts {
+		opt(b)
+	}
+	return b
+}
+
+// Selection 一次选择的结果, 调用结束后需调用 Done 反馈结果
+type Selection struct {
+	Endpoint
+
+	b    *Balancer
+	ep   *balancedEndpoint
+	once sync.Once
+}
+
+// Done 反馈本次调用结果, err 不为 nil 时计入节点的连续失败次数, 多次调用只有第一次生效
+func (s *Selection) Done(err error) {
+	s.once.Do(func() {
+		s.ep.outstanding.Add(-1)
+		s.b.feedback(s.ep, err)
+	})
+}
+
+// release 只释放处理中的请求计数, 不影响摘除统计
+func (s *Selection) release() {
+	s.once.Do(func() {
+		s.ep.outstanding.Add(-1)
+	})
+}
+
+// Pick 按策略选择一个节点, key 仅在 ConsistentHash 策略下使用, 为空时退化为轮询
+func (b *Balancer) Pick(key string) (*Selection, error) {
+	b.lastUsed.Store(time.Now().UnixNano())
+	b.once.Do(b.start)
+	if !b.watching {
+		b.refreshIfStale()
+	}
+
+	sel := b.pick(key)
+	if sel == nil {
+		return nil, errors.Wrapf(ErrNoEndpoint, "%s:%s", b.service, b.tag)
+	}
+	return sel, nil
+}
+
+// Update 替换节点列表, 已存在节点的统计与摘除状态会被保留
+func (b *Balancer) Update(endpoints []Endpoint) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	old := make(map[string]*balancedEndpoint, len(b.endpoints))
+	for _, ep := range b.endpoints {
+		old[ep.Address] = ep
+	}
+
+	eps := make([]*balancedEndpoint, 0, len(endpoints))
+	for _, e := range endpoints {
+		if ep, ok := old[e.Address]; ok {
+			ep.Endpoint = e
+			eps = append(eps, ep)
+			continue
+		}
+		eps = append(eps, &balancedEndpoint{Endpoint: e})
+	}
+
+	b.endpoints = eps
+	b.refreshedAt = time.Now()
+	if b.strategy == ConsistentHash {
+		b.ring = buildRing(eps)
+	}
+}
+
+// Close 停止订阅节点变化
+func (b *Balancer) Close() {
+	b.cancel()
+}
+
+func (b *Balancer) getFinder() ServiceFinder {
+	if b.finder != nil {
+		return b.finder
+	}
+	return GetServiceFinder()
+}
+
+func (b *Balancer) start() {
+	if w, ok := b.getFinder().(Watcher); ok {
+		b.watching = true
+		ch := w.Watch(b.ctx, b.service, b.tag)
+
+		// 等待首个节点列表, 避免首次选择时没有节点
+		select {
+		case eps, ok := <-ch:
+			if ok {
+				b.Update(eps)
+			}
+		case <-time.After(defaultResolveTimeout):
+		}
+		go func() {
+			for eps := range ch {
+				b.Update(eps)
+			}
+		}()
+		return
+	}
+	b.refresh()
+}
+
+func (b *Balancer) refreshIfStale() {
+	b.mu.RLock()
+	stale := time.Since(b.refreshedAt) > b.refreshInterval
+	b.mu.RUnlock()
+
+	if stale {
+		b.refresh()
+	}
+}
+
+func (b *Balancer) refresh() {
+	b.refreshMu.Lock()
+	defer b.refreshMu.Unlock()
+
+	b.mu.RLock()
+	fresh := time.Since(b.refreshedAt) <= b.refreshInterval
+	b.mu.RUnlock()
+	if fresh {
+		return
+	}
+
+	addrs := b.getFinder().GetAllAddressWithTag(b.service, b.tag)
+	if len(addrs) == 0 {
+		// 保留上一次获取到的节点列表
+		b.mu.Lock()
+		b.refreshedAt = time.Now()
+		b.mu.Unlock()
+		return
+	}
+
+	slices.Sort(addrs)
+	eps := make([]Endpoint, 0, len(addrs))
+	for _, addr := range addrs {
+		eps = append(eps, Endpoint{Address: addr, Weight: 1})
+	}
+	b.Update(eps)
+}
+
+// candidates 返回未被摘除的节点, 全部被摘除时返回全部节点
+func (b *Balancer) candidates(now time.Time) []*balancedEndpoint {
+	healthy := make([]*balancedEndpoint, 0, len(b.endpoints))
+	for _, ep := range b.endpoints {
+		if !now.Before(ep.ejectedUntil) {
+			healthy = append(healthy, ep)
+		}
+	}
+	if len(healthy) == 0 {
+		return b.endpoints
+	}
+	return healthy
+}
+
+// pick 在读锁内完成选择, 避免与 Update 并发修改节点信息
+func (b *Balancer) pick(key string) *Selection {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	if len(b.endpoints) == 0 {
+		return nil
+	}
+
+	ep := b.choose(key)
+	ep.outstanding.Add(1)
+	return &Selection{Endpoint: ep.Endpoint, b: b, ep: ep}
+}
+
+func (b *Balancer) choose(key string) *balancedEndpoint {
+	now := time.Now()
+	if b.strategy == ConsistentHash && key != "" {
+		return b.pickHash(key, now)
+	}
+
+	eps := b.candidates(now)
+	switch b.strategy {
+	case WeightedRandom:
+		return pickWeighted(eps)
+	case LeastOutstanding:
+		return pickLeastOutstanding(eps)
+	default:
+		return eps[b.next.Add(1)%uint64(len(eps))]
+	}
+}
+
+func pickWeighted(eps []*balancedEndpoint) *balancedEndpoint {
+	total := 0
+	for _, ep := range eps {
+		total += ep.Weight
+	}
+	if total <= 0 {
+		return eps[rand.N(len(eps))]
+	}
+
+	n := rand.N(total)
+	for _, ep := range eps {
+		n -= ep.Weight
+		if n < 0 {
+			return ep
+		}
+	}
+	return eps[len(eps)-1]
+}
+
+// pickLeastOutstanding power of two choices
+func pickLeastOutstanding(eps []*balancedEndpoint) *balancedEndpoint {
+	if len(eps) == 1 {
+		return eps[0]
+	}
+
+	i := rand.N(len(eps))
+	j := rand.N(len(eps) - 1)
+	if j >= i {
+		j++
+	}
+
+	a, b := eps[i], eps[j]
+	// 按权重归一化处理中的请求数
+	if a.outstanding.Load()*int64(b.Weight) <= b.outstanding.Load()*int64(a.Weight) {
+		return a
+	}
+	return b
+}
+
+func buildRing(eps []*balancedEndpoint) []hashNode {
+	ring := make([]hashNode, 0, len(eps)*consistentHashVirtualNodes)
+	for _, ep := range eps {
+		for i := range consistentHashVirtualNodes {
+			ring = append(ring, hashNode{
+				hash: crc32.ChecksumIEEE([]byte(ep.Address + "#" + strconv.Itoa(i))),
+				ep:   ep,
+			})
+		}
+	}
+	slices.SortFunc(ring, func(a, b hashNode) int {
+		if a.hash < b.hash {
+			return -1
+		} else if a.hash > b.hash {
+			return 1
+		}
+		return 0
+	})
+	return ring
+}
+
+// pickHash 顺时针查找第一个未被摘除的节点, 全部被摘除时使用 key 对应的节点
+func (b *Balancer) pickHash(key string, now time.Time) *balancedEndpoint {
+	h := crc32.ChecksumIEEE([]byte(key))
+	start, _ := slices.BinarySearchFunc(b.ring, h, func(n hashNode, h uint32) int {
+		if n.hash < h {
+			return -1
+		} else if n.hash > h {
+			return 1
+		}
+		return 0
+	})
+
+	for i := range b.ring {
+		node := b.ring[(start+i)%len(b.ring)]
+		if !now.Before(node.ep.ejectedUntil) {
+			return node.ep
+		}
+	}
+	return b.ring[start%len(b.ring)].ep
+}
+
+func (b *Balancer) feedback(ep *balancedEndpoint, err error) {
+	if b.ejection.failures <= 0 {
+		return
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil {
+		ep.failures = 0
+		return
+	}
+
+	ep.failures++
+	now := time.Now()
+	if ep.failures < b.ejection.failures || now.Before(ep.ejectedUntil) {
+		return
+	}
+
+	ejected := 0
+	for _, e := range b.endpoints {
+		if now.Before(e.ejectedUntil) {
+			ejected++
+		}
+	}
+	if (ejected+1)*100 > len(b.endpoints)*b.ejection.maxPercent {
+		return
+	}
+
+	ep.ejections++
+	ep.failures = 0
+	d := min(b.ejection.baseTime*time.Duration(ep.ejections), b.ejection.maxTime)
+	ep.ejectedUntil = now.Add(d)
+	innerlog.Logger.Warnf("Eject endpoint %s of %s:%s for %v after %d consecutive failures",
+		ep.Address, b.service, b.tag, d, b.ejection.failures)
+}
+
+var (
+	balancerMu      sync.Mutex
+	balancers       = make(map[serviceKey]*Balancer)
+	balancerOpts    = make(map[string][]BalancerOption)
+	balancerSweptAt time.Time
+)
+
+// SetBalancer 设置 service 使用的负载均衡参数, 对该 service 的所有 tag 生效
+func SetBalancer(service string, opts ...BalancerOption) {
+	balancerMu.Lock()
+	defer balancerMu.Unlock()
+
+	balancerOpts[service] = opts
+	for key, b := range balancers {
+		if key.service == service {
+			b.Close()
+			delete(balancers, key)
+		}
+	}
+}
+
+// GetBalancer 返回 service/tag 的负载均衡器, 未通过 SetBalancer 设置时使用轮询
+// 超过 10m 未被使用的负载均衡器会被关闭并移除, 调用方不应长期持有返回值
+func GetBalancer(service, tag string) *Balancer {
+	key := serviceKey{service: service, tag: tag}
+
+	balancerMu.Lock()
+	defer balancerMu.Unlock()
+
+	evictIdleBalancers(time.Now())
+	b, ok := balancers[key]
+	if !ok {
+		b = NewBalancer(service, tag, balancerOpts[service]...)
+		balancers[key] = b
+	}
+	b.lastUsed.Store(time.Now().UnixNano())
+	return b
+}
+
+// evictIdleBalancers 每隔半个 balancerIdleTimeout 清理一次空闲的负载均衡器, 需持有 balancerMu
+func evictIdleBalancers(now time.Time) {
+	if now.Sub(balancerSweptAt) < balancerIdleTimeout/2 {
+		return
+	}
+	balancerSweptAt = now
+
+	for key, b := range balancers {
+		if now.Sub(time.Unix(0, b.lastUsed.Load())) > balancerIdleTimeout {
+			b.Close()
+			delete(balancers, key)
+		}
+	}
+}
+
+// hasBalancer 判断 service 是否通过 SetBalancer 设置了负载均衡参数
+func hasBalancer(service string) bool {
+	balancerMu.Lock()
+	defer balancerMu.Unlock()
+
+	_, ok := balancerOpts[service]
+	return ok
+}
+
+// resetBalancers 全局 ServiceFinder 变化后, 负载均衡器需要重新订阅
+func resetBalancers() {
+	balancerMu.Lock()
+	defer balancerMu.Unlock()
+
+	for key, b := range balancers {
+		b.Close()
+		delete(balancers, key)
+	}
+}
+
+// Pick 通过 service 的负载均衡器选择一个节点, 调用结束后需调用 Selection.Done
+func Pick(service, key string) (*Selection, error) {
+	return PickWithTag(service, "", key)
+}
+
+func PickWithTag(service, tag, key string) (*Selection, error) {
+	return GetBalancer(service, tag).Pick(key)
+}
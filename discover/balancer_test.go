@@ -0,0 +1,224 @@
+package discover
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// staticFinder 通过 Watch 返回固定的节点列表
+type staticFinder struct {
+	*DirectFinder
+	endpoints []Endpoint
+}
+
+func (f *staticFinder) GetAddressWithTag(service, tag string) string {
+	return "finder:" + service
+}
+
+func (f *staticFinder) GetAllAddress(service string) []string {
+	return f.GetAllAddressWithTag(service, "")
+}
+
+func (f *staticFinder) GetAllAddressWithTag(service, tag string) []string {
+	addrs := make([]string, 0, len(f.endpoints))
+	for _, ep := range f.endpoints {
+		addrs = append(addrs, ep.Address)
+	}
+	return addrs
+}
+
+func (f *staticFinder) Watch(ctx context.Context, service, tag string) <-chan []Endpoint {
+	ch := make(chan []Endpoint, 1)
+	ch <- f.endpoints
+	go func() {
+		<-ctx.Done()
+		close(ch)
+	}()
+	return ch
+}
+
+func newTestBalancer(t *testing.T, strategy Strategy, endpoints []Endpoint, opts ...BalancerOption) *Balancer {
+	t.Helper()
+
+	finder := &staticFinder{DirectFinder: NewDirectFinder(), endpoints: endpoints}
+	opts = append([]BalancerOption{WithStrategy(strategy), WithBalancerFinder(finder)}, opts...)
+	b := NewBalancer("svc", "", opts...)
+	t.Cleanup(b.Close)
+	return b
+}
+
+func testEndpoints(n int) []Endpoint {
+	eps := make([]Endpoint, 0, n)
+	for i := range n {
+		eps = append(eps, Endpoint{Address: fmt.Sprintf("10.0.0.%d:80", i+1), Weight: 1})
+	}
+	return eps
+}
+
+func pickN(t *testing.T, b *Balancer, n int, key string) map[string]int {
+	t.Helper()
+
+	counts := make(map[string]int)
+	for range n {
+		sel, err := b.Pick(key)
+		require.NoError(t, err)
+		counts[sel.Address]++
+		sel.Done(nil)
+	}
+	return counts
+}
+
+func TestBalancerRoundRobin(t *testing.T) {
+	b := newTestBalancer(t, RoundRobin, testEndpoints(3))
+
+	counts := pickN(t, b, 30, "")
+	assert.Len(t, counts, 3)
+	for _, c := range counts {
+		assert.Equal(t, 10, c)
+	}
+}
+
+func TestBalancerWeightedRandom(t *testing.T) {
+	eps := testEndpoints(2)
+	eps[0].Weight = 9
+	b := newTestBalancer(t, WeightedRandom, eps)
+
+	counts := pickN(t, b, 1000, "")
+	assert.Greater(t, counts[eps[0].Address], counts[eps[1].Address]*4)
+}
+
+func TestBalancerLeastOutstanding(t *testing.T) {
+	eps := testEndpoints(2)
+	b := newTestBalancer(t, LeastOutstanding, eps)
+
+	busy, err := b.Pick("")
+	require.NoError(t, err)
+	defer busy.Done(nil)
+
+	for range 10 {
+		sel, err := b.Pick("")
+		require.NoError(t, err)
+		assert.NotEqual(t, busy.Address, sel.Address)
+		sel.Done(nil)
+	}
+}
+
+func TestBalancerConsistentHash(t *testing.T) {
+	b := newTestBalancer(t, ConsistentHash, testEndpoints(5))
+
+	for i := range 20 {
+		key := fmt.Sprintf("user-%d", i)
+		assert.Len(t, pickN(t, b, 5, key), 1)
+	}
+
+	// 增加节点后大部分 key 的归属保持不变
+	before := make(map[string]string)
+	for i := range 100 {
+		key := fmt.Sprintf("user-%d", i)
+		sel, _ := b.Pick(key)
+		before[key] = sel.Address
+		sel.Done(nil)
+	}
+	b.Update(testEndpoints(6))
+
+	moved := 0
+	for key, addr := range before {
+		sel, _ := b.Pick(key)
+		if sel.Address != addr {
+			moved++
+		}
+		sel.Done(nil)
+	}
+	assert.Less(t, moved, 40)
+}
+
+func TestBalancerOutlierEjection(t *testing.T) {
+	eps := testEndpoints(3)
+	b := newTestBalancer(t, RoundRobin, eps, WithOutlierEjection(2, time.Hour, 50))
+
+	failed := 0
+	for failed < 2 {
+		sel, err := b.Pick("")
+		require.NoError(t, err)
+		if sel.Address == eps[0].Address {
+			sel.Done(errors.New("boom"))
+			failed++
+			continue
+		}
+		sel.Done(nil)
+	}
+
+	counts := pickN(t, b, 20, "")
+	assert.Zero(t, counts[eps[0].Address])
+
+	// 超过最大摘除比例时不再摘除
+	for range 10 {
+		sel, err := b.Pick("")
+		require.NoError(t, err)
+		sel.Done(errors.New("boom"))
+	}
+	assert.Len(t, pickN(t, b, 20, ""), 2)
+}
+
+func TestBalancerNoEndpoint(t *testing.T) {
+	b := newTestBalancer(t, RoundRobin, nil)
+
+	_, err := b.Pick("")
+	assert.ErrorIs(t, err, ErrNoEndpoint)
+}
+
+func TestGetAddressDirectFinder(t *testing.T) {
+	assert.Equal(t, "svc", GetAddress("svc"))
+	assert.Equal(t, "127.0.0.1:80", GetAddress("127.0.0.1:80"))
+}
+
+func TestGetAddressWithTagBalancer(t *testing.T) {
+	eps := testEndpoints(2)
+	SetFinder(&staticFinder{DirectFinder: NewDirectFinder(), endpoints: eps})
+	t.Cleanup(func() {
+		balancerMu.Lock()
+		delete(balancerOpts, "svc")
+		balancerMu.Unlock()
+		SetFinder(NewDirectFinder())
+	})
+
+	// 未设置负载均衡参数时使用 ServiceFinder 自身的选择
+	assert.Equal(t, "finder:svc", GetAddressWithTag("svc", "v1"))
+	assert.Equal(t, "127.0.0.1:80", GetAddressWithTag("127.0.0.1:80", "v1"))
+	assert.Equal(t, []string{eps[0].Address, eps[1].Address}, GetAddresses("svc"))
+	assert.Equal(t, []string{"127.0.0.1:80"}, GetAddresses("127.0.0.1:80"))
+
+	SetBalancer("svc", WithStrategy(RoundRobin))
+	got := map[string]bool{}
+	for range 4 {
+		got[GetAddressWithTag("svc", "v1")] = true
+	}
+	assert.Equal(t, map[string]bool{eps[0].Address: true, eps[1].Address: true}, got)
+}
+
+func TestGetBalancerEvictIdle(t *testing.T) {
+	idle, swept := balancerIdleTimeout, balancerSweptAt
+	balancerIdleTimeout = time.Millisecond * 20
+	t.Cleanup(func() {
+		balancerIdleTimeout, balancerSweptAt = idle, swept
+		resetBalancers()
+	})
+
+	a := GetBalancer("svc-a", "")
+	assert.Same(t, a, GetBalancer("svc-a", ""))
+
+	time.Sleep(balancerIdleTimeout * 2)
+	GetBalancer("svc-b", "")
+
+	balancerMu.Lock()
+	_, ok := balancers[serviceKey{service: "svc-a"}]
+	balancerMu.Unlock()
+	assert.False(t, ok)
+	assert.NotSame(t, a, GetBalancer("svc-a", ""))
+}
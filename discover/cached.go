@@ -0,0 +1,434 @@
+// File:		cached.go
+// Created by:	Hoven
+// Created on:	2026-10-17
+//
+// This file is part of the Example Project.
+//
+// (c) 2024 Example Corp. All rights reserved.
+
+package discover
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"math/rand/v2"
+	"net"
+	"slices"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/miebyte/goutils/consulutils"
+	"github.com/miebyte/goutils/internal/innerlog"
+)
+
+var (
+	defaultWatchWaitTime  = time.Minute * 5
+	defaultResolveTimeout = time.Second * 3
+	defaultIdleTimeout    = time.Minute * 10
+	watchMinBackoff       = time.Second
+	watchMaxBackoff       = time.Second * 30
+)
+
+// Endpoint 服务的一个可用节点
+type Endpoint struct {
+	Address string
+	Weight  int
+	Tags    []string
+	Meta    map[string]string
+}
+
+// Watcher 支持监听节点变化的 ServiceFinder, 如 CachedFinder
+type Watcher interface {
+	Watch(ctx context.Context, service, tag string) <-chan []Endpoint
+}
+
+type serviceKey struct {
+	service string
+	tag     string
+}
+
+func (k serviceKey) String() string {
+	return fmt.Sprintf("%s:%s", k.service, k.tag)
+}
+
+type serviceEntry struct {
+	key   serviceKey
+	ready chan struct{}
+	once  sync.Once
+
+	ctx        context.Context
+	cancel     context.CancelFunc
+	lastAccess atomic.Int64
+
+	mu        sync.RWMutex
+	endpoints []Endpoint
+	watchers  map[chan []Endpoint]struct{}
+}
+
+// CachedFinder 基于 consul blocking query 的服务发现
+//
+// 首次查询某个 service/tag 时开始监听, 之后的查询直接读取本地节点表。
+// consul 不可用时保留最后一次成功获取的节点列表。
+// 超过 idle timeout 未被查询且没有 Watch 的 service/tag 会停止监听并从节点表中移除。
+type CachedFinder struct {
+	client         *consulutils.Client
+	waitTime       time.Duration
+	resolveTimeout time.Duration
+	idleTimeout    time.Duration
+
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+
+	mu      sync.Mutex
+	entries map[serviceKey]*serviceEntry
+}
+
+type CachedFinderOption func(*CachedFinder)
+
+// WithWatchWaitTime 设置 blocking query 的最长等待时间, 默认 5m
+func WithWatchWaitTime(d time.Duration) CachedFinderOption {
+	return func(f *CachedFinder) {
+		if d > 0 {
+			f.waitTime = d
+		}
+	}
+}
+
+// WithResolveTimeout 设置首次查询某个服务时等待结果的最长时间, 默认 3s
+func WithResolveTimeout(d time.Duration) CachedFinderOption {
+	return func(f *CachedFinder) {
+		if d > 0 {
+			f.resolveTimeout = d
+		}
+	}
+}
+
+// WithIdleTimeout 设置 service/tag 未被查询多久后停止监听, 默认 10m
+func WithIdleTimeout(d time.Duration) CachedFinderOption {
+	return func(f *CachedFinder) {
+		if d > 0 {
+			f.idleTimeout = d
+		}
+	}
+}
+
+func NewCachedFinder(client *consulutils.Client, opts ...CachedFinderOption) *CachedFinder {
+	f := &CachedFinder{
+		client:         client,
+		waitTime:       defaultWatchWaitTime,
+		resolveTimeout: defaultResolveTimeout,
+		idleTimeout:    defaultIdleTimeout,
+		entries:        make(map[serviceKey]*serviceEntry),
+	}
+	f.ctx, f.cancel = context.WithCancel(context.Background())
+
+	for _, opt := range opts {
+		opt(f)
+	}
+
+	f.wg.Add(1)
+	go func() {
+		defer f.wg.Done()
+		f.evictIdle()
+	}()
+	return f
+}
+
+// SetCachedConsulFinder 使用基于默认 consul 客户端的 CachedFinder 作为全局服务发现
+func SetCachedConsulFinder(opts ...CachedFinderOption) {
+	SetFinder(NewCachedFinder(consulutils.GetConsulClient(), opts...))
+}
+
+// Endpoints 返回 service/tag 当前的可用节点, 首次调用时会等待第一次查询结果
+func (f *CachedFinder) Endpoints(service, tag string) []Endpoint {
+	e := f.entry(serviceKey{service: service, tag: tag})
+
+	select {
+	case <-e.ready:
+	case <-time.After(f.resolveTimeout):
+		innerlog.Logger.Warnf("Resolve %s timeout after %v", e.key, f.resolveTimeout)
+	}
+
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	return slices.Clone(e.endpoints)
+}
+
+// Watch 监听 service/tag 的节点变化, 已有节点列表时会先发送一次当前值
+// channel 只保留最新的节点列表, ctx 结束或 finder 关闭后 channel 被关闭
+func (f *CachedFinder) Watch(ctx context.Context, service, tag string) <-chan []Endpoint {
+	e := f.entry(serviceKey{service: service, tag: tag})
+	ch := make(chan []Endpoint, 1)
+
+	e.mu.Lock()
+	if f.ctx.Err() != nil {
+		e.mu.Unlock()
+		close(ch)
+		return ch
+	}
+	e.watchers[ch] = struct{}{}
+	select {
+	case <-e.ready:
+		ch <- slices.Clone(e.endpoints)
+	default:
+	}
+	e.mu.Unlock()
+
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-f.ctx.Done():
+		}
+
+		e.mu.Lock()
+		if _, ok := e.watchers[ch]; ok {
+			delete(e.watchers, ch)
+			close(ch)
+		}
+		e.mu.Unlock()
+		e.lastAccess.Store(time.Now().UnixNano())
+	}()
+	return ch
+}
+
+func (f *CachedFinder) entry(key serviceKey) *serviceEntry {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	e, ok := f.entries[key]
+	if ok {
+		e.lastAccess.Store(time.Now().UnixNano())
+		return e
+	}
+
+	e = &serviceEntry{
+		key:      key,
+		ready:    make(chan struct{}),
+		watchers: make(map[chan []Endpoint]struct{}),
+	}
+	e.ctx, e.cancel = context.WithCancel(f.ctx)
+	e.lastAccess.Store(time.Now().UnixNano())
+	f.entries[key] = e
+
+	if f.ctx.Err() == nil {
+		f.wg.Add(1)
+		go func() {
+			defer f.wg.Done()
+			f.watch(e)
+		}()
+	}
+	return e
+}
+
+// evictIdle 定期移除长时间未被查询且没有 Watch 的 service/tag, 并停止其 blocking query
+func (f *CachedFinder) evictIdle() {
+	ticker := time.NewTicker(max(f.idleTimeout/2, time.Millisecond))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-f.ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		deadline := time.Now().Add(-f.idleTimeout).UnixNano()
+		f.mu.Lock()
+		for key, e := range f.entries {
+			if e.lastAccess.Load() > deadline {
+				continue
+			}
+
+			e.mu.RLock()
+			watched := len(e.watchers) != 0
+			e.mu.RUnlock()
+			if watched {
+				continue
+			}
+
+			delete(f.entries, key)
+			e.cancel()
+			innerlog.Logger.Debugf("Stop watching %s, idle for %v", key, f.idleTimeout)
+		}
+		f.mu.Unlock()
+	}
+}
+
+// watch 通过 blocking query 持续同步节点列表, 出错时按指数退避重试
+func (f *CachedFinder) watch(e *serviceEntry) {
+	var index uint64
+	backoff := watchMinBackoff
+
+	for {
+		opts := (&api.QueryOptions{WaitIndex: index, WaitTime: f.waitTime}).WithContext(e.ctx)
+		entries, meta, err := f.client.Health().Service(e.key.service, e.key.tag, true, opts)
+		if e.ctx.Err() != nil {
+			return
+		}
+
+		if err != nil {
+			// 首次查询失败时不再阻塞查询方
+			e.markReady()
+			innerlog.Logger.Warnf("Watch %s in consul failed, keep last endpoints, retry in %v. err: %v", e.key, backoff, err)
+
+			select {
+			case <-e.ctx.Done():
+				return
+			case <-time.After(backoff):
+			}
+			backoff = min(backoff*2, watchMaxBackoff)
+			continue
+		}
+		backoff = watchMinBackoff
+
+		// index 回退时(如 consul 重建数据)需要重新从头查询
+		if meta.LastIndex < index {
+			index = 0
+		} else {
+			index = meta.LastIndex
+		}
+
+		e.update(toEndpoints(entries))
+	}
+}
+
+func (e *serviceEntry) markReady() {
+	e.once.Do(func() {
+		close(e.ready)
+	})
+}
+
+func (e *serviceEntry) update(endpoints []Endpoint) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	defer e.markReady()
+
+	select {
+	case <-e.ready:
+		if equalEndpoints(e.endpoints, endpoints) {
+			return
+		}
+	default:
+	}
+
+	e.endpoints = endpoints
+	innerlog.Logger.Debugf("Endpoints of %s changed. Count=%d", e.key, len(endpoints))
+
+	for ch := range e.watchers {
+		// 丢弃未被消费的旧值, 只保留最新的节点列表
+		select {
+		case <-ch:
+		default:
+		}
+		ch <- slices.Clone(endpoints)
+	}
+}
+
+func toEndpoints(entries []*api.ServiceEntry) []Endpoint {
+	endpoints := make([]Endpoint, 0, len(entries))
+	for _, s := range entries {
+		host := s.Service.Address
+		if host == "" {
+			host = s.Node.Address
+		}
+
+		weight := s.Service.Weights.Passing
+		if weight <= 0 {
+			weight = 1
+		}
+
+		endpoints = append(endpoints, Endpoint{
+			Address: net.JoinHostPort(host, fmt.Sprint(s.Service.Port)),
+			Weight:  weight,
+			Tags:    s.Service.Tags,
+			Meta:    s.Service.Meta,
+		})
+	}
+
+	slices.SortFunc(endpoints, func(a, b Endpoint) int {
+		if a.Address < b.Address {
+			return -1
+		} else if a.Address > b.Address {
+			return 1
+		}
+		return 0
+	})
+	return endpoints
+}
+
+func equalEndpoints(a, b []Endpoint) bool {
+	return slices.EqualFunc(a, b, func(x, y Endpoint) bool {
+		return x.Address == y.Address && x.Weight == y.Weight &&
+			slices.Equal(x.Tags, y.Tags) && maps.Equal(x.Meta, y.Meta)
+	})
+}
+
+func (f *CachedFinder) GetAddress(service string) string {
+	return f.GetAddressWithTag(service, "")
+}
+
+func (f *CachedFinder) GetAllAddress(service string) []string {
+	return f.GetAllAddressWithTag(service, "")
+}
+
+func (f *CachedFinder) GetAddressWithTag(service, tag string) string {
+	if IsAddress(service) {
+		return service
+	}
+
+	endpoints := f.Endpoints(service, tag)
+	if len(endpoints) == 0 {
+		innerlog.Logger.Errorf("Failed to find %s:%s in consul.", service, tag)
+		return ""
+	}
+	return endpoints[rand.N(len(endpoints))].Address
+}
+
+func (f *CachedFinder) GetAllAddressWithTag(service, tag string) []string {
+	if IsAddress(service) {
+		return []string{service}
+	}
+
+	endpoints := f.Endpoints(service, tag)
+	if len(endpoints) == 0 {
+		innerlog.Logger.Errorf("Failed to find %s:%s in consul.", service, tag)
+		return nil
+	}
+
+	addrs := make([]string, 0, len(endpoints))
+	for _, ep := range endpoints {
+		addrs = append(addrs, ep.Address)
+	}
+	rand.Shuffle(len(addrs), func(i, j int) {
+		addrs[i], addrs[j] = addrs[j], addrs[i]
+	})
+	return addrs
+}
+
+func (f *CachedFinder) RegisterService(service, address string) error {
+	return f.client.RegisterService(service, address)
+}
+
+func (f *CachedFinder) RegisterServiceWithTag(service, address, tag string) error {
+	return f.client.RegisterServiceWithTag(service, address, tag)
+}
+
+func (f *CachedFinder) RegisterServiceWithTags(service, address string, tags []string) error {
+	return f.client.RegisterServiceWithTags(service, address, tags)
+}
+
+// RegisterServiceWithOptions 使 cores 注册服务时可以携带注册参数
+func (f *CachedFinder) RegisterServiceWithOptions(service, address string, opts ...consulutils.RegisterOption) error {
+	return f.client.RegisterServiceWithOptions(service, address, opts...)
+}
+
+// Close 停止所有监听并注销通过该 finder 注册的服务
+func (f *CachedFinder) Close() {
+	f.cancel()
+	f.wg.Wait()
+	f.client.Close()
+}
@@ -0,0 +1,90 @@
+package discover
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/consul/api"
+	"github.com/miebyte/goutils/consulutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newFakeConsul 首次查询立即返回一个节点, 之后的 blocking query 一直阻塞到请求结束
+func newFakeConsul(t *testing.T) (*consulutils.Client, *atomic.Int32) {
+	var blocking atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/v1/health/service/") {
+			http.NotFound(w, r)
+			return
+		}
+
+		if r.URL.Query().Get("index") != "" {
+			blocking.Add(1)
+			defer blocking.Add(-1)
+			<-r.Context().Done()
+			return
+		}
+
+		w.Header().Set("X-Consul-Index", "1")
+		json.NewEncoder(w).Encode([]*api.ServiceEntry{{
+			Node:    &api.Node{Address: "10.0.0.1"},
+			Service: &api.AgentService{Port: 8080},
+		}})
+	}))
+	t.Cleanup(srv.Close)
+
+	config := api.DefaultConfig()
+	config.Address = strings.TrimPrefix(srv.URL, "http://")
+	client, err := api.NewClient(config)
+	require.NoError(t, err)
+	return &consulutils.Client{Client: client}, &blocking
+}
+
+func TestCachedFinderEvictIdle(t *testing.T) {
+	client, blocking := newFakeConsul(t)
+	f := NewCachedFinder(client, WithIdleTimeout(time.Millisecond*100))
+	defer f.cancel()
+
+	assert.Equal(t, []string{"10.0.0.1:8080"}, f.GetAllAddress("svc"))
+	assert.Eventually(t, func() bool { return blocking.Load() == 1 }, time.Second, time.Millisecond*10)
+
+	assert.Eventually(t, func() bool {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		return len(f.entries) == 0
+	}, time.Second, time.Millisecond*10)
+	assert.Eventually(t, func() bool { return blocking.Load() == 0 }, time.Second, time.Millisecond*10)
+
+	// 被移除后再次查询会重新开始监听
+	assert.Equal(t, "10.0.0.1:8080", f.GetAddress("svc"))
+}
+
+func TestCachedFinderKeepWatched(t *testing.T) {
+	client, _ := newFakeConsul(t)
+	f := NewCachedFinder(client, WithIdleTimeout(time.Millisecond*50))
+	defer f.cancel()
+
+	ch := f.Watch(t.Context(), "svc", "")
+	assert.Equal(t, []Endpoint{{Address: "10.0.0.1:8080", Weight: 1}}, <-ch)
+
+	time.Sleep(time.Millisecond * 200)
+	f.mu.Lock()
+	assert.Len(t, f.entries, 1)
+	f.mu.Unlock()
+}
+
+func TestCachedFinderAddress(t *testing.T) {
+	client, _ := newFakeConsul(t)
+	f := NewCachedFinder(client)
+	defer f.cancel()
+
+	assert.Equal(t, "127.0.0.1:80", f.GetAddress("127.0.0.1:80"))
+	assert.Equal(t, []string{"127.0.0.1:80"}, f.GetAllAddress("127.0.0.1:80"))
+	assert.Empty(t, f.entries)
+}
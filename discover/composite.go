@@ -0,0 +1,91 @@
+// File:		composite.go
+// Created by:	Hoven
+// Created on:	2026-10-17
+//
+// This file is part of the Example Project.
+//
+// (c) 2024 Example Corp. All rights reserved.
+
+package discover
+
+import (
+	"github.com/miebyte/goutils/consulutils"
+)
+
+// CompositeFinder 按顺序依次查询多个 ServiceFinder, 使用第一个返回结果的 finder
+//
+// DirectFinder 总是返回服务名本身, 需放在最后作为兜底。注册服务时会注册到所有 finder。
+type CompositeFinder struct {
+	finders []ServiceFinder
+}
+
+func NewCompositeFinder(finders ...ServiceFinder) *CompositeFinder {
+	return &CompositeFinder{finders: finders}
+}
+
+func (f *CompositeFinder) GetAddress(service string) string {
+	return f.GetAddressWithTag(service, "")
+}
+
+func (f *CompositeFinder) GetAllAddress(service string) []string {
+	return f.GetAllAddressWithTag(service, "")
+}
+
+func (f *CompositeFinder) GetAddressWithTag(service, tag string) string {
+	for _, finder := range f.finders {
+		if addr := finder.GetAddressWithTag(service, tag); addr != "" {
+			return addr
+		}
+	}
+	return ""
+}
+
+func (f *CompositeFinder) GetAllAddressWithTag(service, tag string) []string {
+	for _, finder := range f.finders {
+		if addrs := finder.GetAllAddressWithTag(service, tag); len(addrs) != 0 {
+			return addrs
+		}
+	}
+	return nil
+}
+
+func (f *CompositeFinder) RegisterService(service, address string) error {
+	return f.RegisterServiceWithTags(service, address, nil)
+}
+
+func (f *CompositeFinder) RegisterServiceWithTag(service, address, tag string) error {
+	return f.RegisterServiceWithTags(service, address, []string{tag})
+}
+
+func (f *CompositeFinder) RegisterServiceWithTags(service, address string, tags []string) error {
+	for _, finder := range f.finders {
+		if err := finder.RegisterServiceWithTags(service, address, tags); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RegisterServiceWithOptions 支持注册参数的 finder 使用 opts 注册, 其余 finder 使用 opts 中的 tag 注册
+func (f *CompositeFinder) RegisterServiceWithOptions(service, address string, opts ...consulutils.RegisterOption) error {
+	for _, finder := range f.finders {
+		var err error
+		if r, ok := finder.(interface {
+			RegisterServiceWithOptions(service, address string, opts ...consulutils.RegisterOption) error
+		}); ok {
+			err = r.RegisterServiceWithOptions(service, address, opts...)
+		} else {
+			err = finder.RegisterServiceWithTags(service, address, consulutils.RegisterOptionTags(opts...))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *CompositeFinder) Close() {
+	for _, finder := range f.finders {
+		finder.Close()
+	}
+}
@@ -9,6 +9,8 @@
 package discover
 
 import (
+	"context"
+	"net"
 	"sync"
 
 	"github.com/miebyte/goutils/consulutils"
@@ -49,25 +51,68 @@ func GetServiceFinder() ServiceFinder {
 
 func SetFinder(finder ServiceFinder) {
 	finderMutex.Lock()
-	defer finderMutex.Unlock()
 	defaultServiceFinder = finder
+	finderMutex.Unlock()
+
+	resetBalancers()
 }
 
 func SetConsulFinder() {
-	finderMutex.Lock()
-	defer finderMutex.Unlock()
+	SetFinder(consulutils.GetConsulClient())
+}
 
-	defaultServiceFinder = consulutils.GetConsulClient()
+// IsAddress service 本身就是 ip 或 ip:port 时无需查询
+func IsAddress(service string) bool {
+	host, _, err := net.SplitHostPort(service)
+	if err != nil {
+		host = service
+	}
+	return host == "localhost" || net.ParseIP(host) != nil
 }
 
+// GetAddress 返回 srv 的一个节点地址
+// 通过 SetBalancer 设置过负载均衡参数时由负载均衡器选择, 否则由全局 ServiceFinder 选择
 func GetAddress(srv string) string {
+	if IsAddress(srv) {
+		return srv
+	}
+	if hasBalancer(srv) {
+		return pickAddress(srv, "")
+	}
 	return GetServiceFinder().GetAddress(srv)
 }
 
 func GetAddresses(srv string) []string {
+	if IsAddress(srv) {
+		return []string{srv}
+	}
 	return GetServiceFinder().GetAllAddress(srv)
 }
 
 func GetAddressWithTag(srv, tag string) string {
+	if IsAddress(srv) {
+		return srv
+	}
+	if hasBalancer(srv) {
+		return pickAddress(srv, tag)
+	}
 	return GetServiceFinder().GetAddressWithTag(srv, tag)
 }
+
+func pickAddress(srv, tag string) string {
+	sel, err := PickWithTag(srv, tag, "")
+	if err != nil {
+		return ""
+	}
+	sel.release()
+	return sel.Address
+}
+
+// Watch 监听 service/tag 的节点变化, 当前的全局 ServiceFinder 不支持监听时返回 false
+func Watch(ctx context.Context, srv, tag string) (<-chan []Endpoint, bool) {
+	w, ok := GetServiceFinder().(Watcher)
+	if !ok {
+		return nil, false
+	}
+	return w.Watch(ctx, srv, tag), true
+}
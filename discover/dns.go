@@ -0,0 +1,184 @@
+// File:		dns.go
+// Created by:	Hoven
+// Created on:	2026-10-17
+//
+// This file is part of the Example Project.
+//
+// (c) 2024 Example Corp. All rights reserved.
+
+package discover
+
+import (
+	"context"
+	"math/rand/v2"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miebyte/goutils/internal/innerlog"
+)
+
+var (
+	defaultDNSCacheTTL      = time.Second * 5
+	defaultDNSTimeout       = time.Second * 3
+	defaultDNSRetryInterval = time.Second
+)
+
+// srvResolver 查询 SRV 记录, *net.Resolver 实现了该接口
+type srvResolver interface {
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+}
+
+type dnsRecord struct {
+	addrs     []string
+	expiresAt time.Time
+}
+
+// DNSSRVFinder 通过 DNS SRV 记录发现服务, 适用于 Kubernetes headless service
+//
+// tag 为空时查询 <service>.<domain> 的 SRV 记录, 否则 tag 作为端口名查询 _<tag>._tcp.<service>.<domain>。
+// 查询结果缓存一段时间, 查询失败时使用上一次的结果, 并在 1s 后重试。
+type DNSSRVFinder struct {
+	domain        string
+	resolver      srvResolver
+	cacheTTL      time.Duration
+	timeout       time.Duration
+	retryInterval time.Duration
+
+	mu    sync.Mutex
+	cache map[serviceKey]dnsRecord
+}
+
+type DNSOption func(*DNSSRVFinder)
+
+// WithDNSDomain 设置拼接在服务名之后的域名, 如 "default.svc.cluster.local"
+func WithDNSDomain(domain string) DNSOption {
+	return func(f *DNSSRVFinder) {
+		f.domain = strings.Trim(domain, ".")
+	}
+}
+
+// WithDNSResolver 使用自定义的 resolver, 默认为 net.DefaultResolver
+func WithDNSResolver(resolver *net.Resolver) DNSOption {
+	return func(f *DNSSRVFinder) {
+		if resolver != nil {
+			f.resolver = resolver
+		}
+	}
+}
+
+// WithDNSCacheTTL 设置查询结果的缓存时间, 默认 5s
+func WithDNSCacheTTL(ttl time.Duration) DNSOption {
+	return func(f *DNSSRVFinder) {
+		f.cacheTTL = ttl
+	}
+}
+
+func NewDNSSRVFinder(opts ...DNSOption) *DNSSRVFinder {
+	f := &DNSSRVFinder{
+		resolver:      net.DefaultResolver,
+		cacheTTL:      defaultDNSCacheTTL,
+		timeout:       defaultDNSTimeout,
+		retryInterval: defaultDNSRetryInterval,
+		cache:         make(map[serviceKey]dnsRecord),
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f
+}
+
+func (f *DNSSRVFinder) name(service string) string {
+	if f.domain == "" {
+		return service
+	}
+	return service + "." + f.domain
+}
+
+func (f *DNSSRVFinder) lookup(service, tag string) []string {
+	key := serviceKey{service: service, tag: tag}
+	now := time.Now()
+
+	f.mu.Lock()
+	record, ok := f.cache[key]
+	f.mu.Unlock()
+	if ok && now.Before(record.expiresAt) {
+		return record.addrs
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), f.timeout)
+	defer cancel()
+
+	proto := ""
+	if tag != "" {
+		proto = "tcp"
+	}
+	_, srvs, err := f.resolver.LookupSRV(ctx, tag, proto, f.name(service))
+	if err != nil {
+		innerlog.Logger.Errorf("Lookup SRV of %s failed. err: %v", key, err)
+		// 缓存上一次的结果, 避免 DNS 不可用期间每次调用都等待查询超时
+		f.mu.Lock()
+		f.cache[key] = dnsRecord{addrs: record.addrs, expiresAt: now.Add(min(f.retryInterval, f.cacheTTL))}
+		f.mu.Unlock()
+		return record.addrs
+	}
+
+	addrs := make([]string, 0, len(srvs))
+	for _, srv := range srvs {
+		addrs = append(addrs, net.JoinHostPort(strings.TrimSuffix(srv.Target, "."), strconv.Itoa(int(srv.Port))))
+	}
+
+	f.mu.Lock()
+	f.cache[key] = dnsRecord{addrs: addrs, expiresAt: now.Add(f.cacheTTL)}
+	f.mu.Unlock()
+	return addrs
+}
+
+func (f *DNSSRVFinder) GetAddress(service string) string {
+	return f.GetAddressWithTag(service, "")
+}
+
+func (f *DNSSRVFinder) GetAllAddress(service string) []string {
+	return f.GetAllAddressWithTag(service, "")
+}
+
+func (f *DNSSRVFinder) GetAddressWithTag(service, tag string) string {
+	addrs := f.lookup(service, tag)
+	if len(addrs) == 0 {
+		return ""
+	}
+	return addrs[rand.N(len(addrs))]
+}
+
+func (f *DNSSRVFinder) GetAllAddressWithTag(service, tag string) []string {
+	addrs := f.lookup(service, tag)
+	if len(addrs) == 0 {
+		return nil
+	}
+
+	ret := make([]string, len(addrs))
+	copy(ret, addrs)
+	rand.Shuffle(len(ret), func(i, j int) {
+		ret[i], ret[j] = ret[j], ret[i]
+	})
+	return ret
+}
+
+// RegisterService 服务由 DNS(如 Kubernetes)维护, 无需注册
+func (f *DNSSRVFinder) RegisterService(service, address string) error {
+	return nil
+}
+
+func (f *DNSSRVFinder) RegisterServiceWithTag(service, address, tag string) error {
+	return nil
+}
+
+func (f *DNSSRVFinder) RegisterServiceWithTags(service, address string, tags []string) error {
+	return nil
+}
+
+func (f *DNSSRVFinder) Close() {
+	// do nothing
+}
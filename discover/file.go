@@ -0,0 +1,191 @@
+// File:		file.go
+// Created by:	Hoven
+// Created on:	2026-10-17
+//
+// This file is part of the Example Project.
+//
+// (c) 2024 Example Corp. All rights reserved.
+
+package discover
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/miebyte/goutils/internal/fswatch"
+	"github.com/miebyte/goutils/internal/innerlog"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+// FileFinder 从本地文件读取服务地址, 适用于本地开发
+//
+// 文件为 JSON 或 YAML(按扩展名 .yaml/.yml 区分), key 为 service 或 service:tag, value 为地址列表:
+//
+//	{"user-service": ["127.0.0.1:8080"], "user-service:grpc": ["127.0.0.1:9090"]}
+//
+// 查询带 tag 的服务但文件中没有对应的 service:tag 时使用 service 的地址。
+// 文件变更(包括 Kubernetes ConfigMap 替换 ..data 软链接)后自动重新加载, 加载失败时保留旧内容。
+type FileFinder struct {
+	path string
+
+	mu       sync.RWMutex
+	services map[string][]string
+
+	cancel context.CancelFunc
+	done   chan struct{}
+}
+
+func NewFileFinder(path string) (*FileFinder, error) {
+	path, err := filepath.Abs(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "abs path")
+	}
+
+	f := &FileFinder{path: path, done: make(chan struct{})}
+	if err := f.load(); err != nil {
+		return nil, err
+	}
+
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, errors.Wrap(err, "create watcher")
+	}
+	if err := w.Add(filepath.Dir(path)); err != nil {
+		_ = w.Close()
+		return nil, errors.Wrapf(err, "watch dir(%s)", filepath.Dir(path))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	f.cancel = cancel
+	go f.watch(ctx, w)
+	return f, nil
+}
+
+func (f *FileFinder) load() error {
+	b, err := os.ReadFile(f.path)
+	if err != nil {
+		return errors.Wrap(err, "readFile")
+	}
+	// 文件被截断后尚未写入完成时内容为空, 保留旧内容等待下一次变更
+	if len(bytes.TrimSpace(b)) == 0 && f.loaded() {
+		return errors.Errorf("services file(%s) is empty", f.path)
+	}
+
+	services := make(map[string][]string)
+	switch strings.ToLower(filepath.Ext(f.path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(b, &services)
+	default:
+		err = json.Unmarshal(b, &services)
+	}
+	if err != nil {
+		return errors.Wrapf(err, "unmarshal services file(%s)", f.path)
+	}
+
+	f.mu.Lock()
+	f.services = services
+	f.mu.Unlock()
+
+	innerlog.Logger.Infof("Load services file success. File=%s Services=%d", f.path, len(services))
+	return nil
+}
+
+func (f *FileFinder) loaded() bool {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.services != nil
+}
+
+func (f *FileFinder) watch(ctx context.Context, w *fsnotify.Watcher) {
+	defer close(f.done)
+	defer w.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case ev, ok := <-w.Events:
+			if !ok {
+				return
+			}
+			if !fswatch.FileChanged(ev, f.path) {
+				continue
+			}
+			if err := f.load(); err != nil {
+				innerlog.Logger.Errorf("Reload services file failed, keep old services. err: %v", err)
+			}
+		case err, ok := <-w.Errors:
+			if !ok {
+				return
+			}
+			innerlog.Logger.Errorf("Watch services file error: %v", err)
+		}
+	}
+}
+
+func (f *FileFinder) lookup(service, tag string) []string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	if tag != "" {
+		if addrs, ok := f.services[service+":"+tag]; ok {
+			return slices.Clone(addrs)
+		}
+	}
+	return slices.Clone(f.services[service])
+}
+
+func (f *FileFinder) GetAddress(service string) string {
+	return f.GetAddressWithTag(service, "")
+}
+
+func (f *FileFinder) GetAllAddress(service string) []string {
+	return f.GetAllAddressWithTag(service, "")
+}
+
+func (f *FileFinder) GetAddressWithTag(service, tag string) string {
+	addrs := f.lookup(service, tag)
+	if len(addrs) == 0 {
+		return ""
+	}
+	return addrs[rand.N(len(addrs))]
+}
+
+func (f *FileFinder) GetAllAddressWithTag(service, tag string) []string {
+	addrs := f.lookup(service, tag)
+	if len(addrs) == 0 {
+		return nil
+	}
+
+	rand.Shuffle(len(addrs), func(i, j int) {
+		addrs[i], addrs[j] = addrs[j], addrs[i]
+	})
+	return addrs
+}
+
+func (f *FileFinder) RegisterService(service, address string) error {
+	return nil
+}
+
+func (f *FileFinder) RegisterServiceWithTag(service, address, tag string) error {
+	return nil
+}
+
+func (f *FileFinder) RegisterServiceWithTags(service, address string, tags []string) error {
+	return nil
+}
+
+// Close 停止监听文件变更
+func (f *FileFinder) Close() {
+	f.cancel()
+	<-f.done
+}
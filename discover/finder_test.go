@@ -0,0 +1,200 @@
+package discover
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/miebyte/goutils/consulutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileFinder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "services.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("user-service:\n  - 127.0.0.1:8080\nuser-service:grpc:\n  - 127.0.0.1:9090\n"), 0o644))
+
+	f, err := NewFileFinder(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	assert.Equal(t, "127.0.0.1:8080", f.GetAddress("user-service"))
+	assert.Equal(t, "127.0.0.1:9090", f.GetAddressWithTag("user-service", "grpc"))
+	// 没有对应 tag 时使用不带 tag 的地址
+	assert.Equal(t, "127.0.0.1:8080", f.GetAddressWithTag("user-service", "http"))
+	assert.Empty(t, f.GetAllAddress("order-service"))
+
+	require.NoError(t, os.WriteFile(path, []byte("user-service: [127.0.0.1:8081, 127.0.0.1:8082]\n"), 0o644))
+	assert.Eventually(t, func() bool {
+		return len(f.GetAllAddress("user-service")) == 2
+	}, time.Second*3, time.Millisecond*10)
+
+	// 加载失败时保留旧内容
+	require.NoError(t, os.WriteFile(path, []byte("user-service: [\n"), 0o644))
+	time.Sleep(time.Millisecond * 100)
+	assert.Len(t, f.GetAllAddress("user-service"), 2)
+}
+
+func TestFileFinderJSON(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "services.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"user-service": ["127.0.0.1:8080"]}`), 0o644))
+
+	f, err := NewFileFinder(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	assert.Equal(t, []string{"127.0.0.1:8080"}, f.GetAllAddress("user-service"))
+
+	_, err = NewFileFinder(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestFileFinderSymlinkSwap(t *testing.T) {
+	// 模拟 Kubernetes ConfigMap 挂载: services.json -> ..data/services.json, ..data -> ..v1
+	dir := t.TempDir()
+	writeVersion := func(name, content string) {
+		require.NoError(t, os.Mkdir(filepath.Join(dir, name), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name, "services.json"), []byte(content), 0o644))
+	}
+	writeVersion("..v1", `{"user-service": ["127.0.0.1:8080"]}`)
+	require.NoError(t, os.Symlink("..v1", filepath.Join(dir, "..data")))
+	require.NoError(t, os.Symlink(filepath.Join("..data", "services.json"), filepath.Join(dir, "services.json")))
+
+	f, err := NewFileFinder(filepath.Join(dir, "services.json"))
+	require.NoError(t, err)
+	defer f.Close()
+	assert.Equal(t, "127.0.0.1:8080", f.GetAddress("user-service"))
+
+	writeVersion("..v2", `{"user-service": ["127.0.0.1:8081"]}`)
+	require.NoError(t, os.Symlink("..v2", filepath.Join(dir, "..data_tmp")))
+	require.NoError(t, os.Rename(filepath.Join(dir, "..data_tmp"), filepath.Join(dir, "..data")))
+	assert.Eventually(t, func() bool {
+		return f.GetAddress("user-service") == "127.0.0.1:8081"
+	}, time.Second*3, time.Millisecond*10)
+}
+
+func TestCompositeFinder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "services.json")
+	require.NoError(t, os.WriteFile(path, []byte(`{"user-service": ["127.0.0.1:8080"]}`), 0o644))
+
+	ff, err := NewFileFinder(path)
+	require.NoError(t, err)
+
+	f := NewCompositeFinder(ff, NewDirectFinder())
+	defer f.Close()
+
+	assert.Equal(t, "127.0.0.1:8080", f.GetAddress("user-service"))
+	assert.Equal(t, []string{"order-service:80"}, f.GetAllAddress("order-service:80"))
+	assert.NoError(t, f.RegisterServiceWithTags("user-service", "127.0.0.1:8080", []string{"dev"}))
+}
+
+type tagsFinder struct {
+	*DirectFinder
+	tags []string
+}
+
+func (f *tagsFinder) RegisterServiceWithTags(service, address string, tags []string) error {
+	f.tags = tags
+	return nil
+}
+
+func TestCompositeFinderRegisterWithOptions(t *testing.T) {
+	tf := &tagsFinder{DirectFinder: NewDirectFinder()}
+	f := NewCompositeFinder(tf)
+
+	// 不支持注册参数的 finder 使用 opts 中的 tag 注册
+	err := f.RegisterServiceWithOptions("user-service", "127.0.0.1:8080",
+		consulutils.WithRegisterTags("dev", "canary"),
+		consulutils.WithRegisterMeta("zone", "a"),
+		consulutils.WithTTLCheck(time.Second),
+	)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"dev", "canary"}, tf.tags)
+}
+
+type fakeResolver struct {
+	mu      sync.Mutex
+	queries []string
+	records map[string][]*net.SRV
+	err     error
+}
+
+func (r *fakeResolver) LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error) {
+	if service != "" || proto != "" {
+		name = "_" + service + "._" + proto + "." + name
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.queries = append(r.queries, name)
+	if r.err != nil {
+		return "", nil, r.err
+	}
+	return name, r.records[name], nil
+}
+
+func (r *fakeResolver) set(records map[string][]*net.SRV, err error) {
+	r.mu.Lock()
+	r.records, r.err = records, err
+	r.mu.Unlock()
+}
+
+func (r *fakeResolver) queryCount() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.queries)
+}
+
+func TestDNSSRVFinder(t *testing.T) {
+	r := &fakeResolver{records: map[string][]*net.SRV{
+		"user-service.default.svc.cluster.local": {
+			{Target: "10-0-0-1.user-service.default.svc.cluster.local.", Port: 8080},
+		},
+		"_grpc._tcp.user-service.default.svc.cluster.local": {
+			{Target: "10-0-0-1.user-service.default.svc.cluster.local.", Port: 9090},
+		},
+	}}
+	f := NewDNSSRVFinder(WithDNSDomain(".default.svc.cluster.local."), WithDNSCacheTTL(time.Hour))
+	f.resolver = r
+
+	assert.Equal(t, "10-0-0-1.user-service.default.svc.cluster.local:8080", f.GetAddress("user-service"))
+	assert.Equal(t, "10-0-0-1.user-service.default.svc.cluster.local:9090", f.GetAddressWithTag("user-service", "grpc"))
+	assert.Empty(t, f.GetAllAddress("order-service"))
+	assert.Equal(t, []string{
+		"user-service.default.svc.cluster.local",
+		"_grpc._tcp.user-service.default.svc.cluster.local",
+		"order-service.default.svc.cluster.local",
+	}, r.queries)
+
+	// 缓存期内不再查询
+	f.GetAllAddress("user-service")
+	f.GetAllAddressWithTag("user-service", "grpc")
+	assert.Equal(t, 3, r.queryCount())
+}
+
+func TestDNSSRVFinderStale(t *testing.T) {
+	records := map[string][]*net.SRV{"user-service": {{Target: "10.0.0.1.", Port: 8080}}}
+	r := &fakeResolver{records: records}
+	f := NewDNSSRVFinder(WithDNSCacheTTL(time.Millisecond * 20))
+	f.resolver = r
+	f.retryInterval = time.Millisecond * 10
+
+	assert.Equal(t, "10.0.0.1:8080", f.GetAddress("user-service"))
+	time.Sleep(time.Millisecond * 30)
+
+	// 查询失败时使用上一次的结果, 重试间隔内不再查询
+	r.set(nil, errors.New("no such host"))
+	assert.Equal(t, "10.0.0.1:8080", f.GetAddress("user-service"))
+	assert.Equal(t, "10.0.0.1:8080", f.GetAddress("user-service"))
+	assert.Equal(t, 2, r.queryCount())
+
+	time.Sleep(time.Millisecond * 20)
+	r.set(map[string][]*net.SRV{"user-service": {{Target: "10.0.0.2.", Port: 8080}}}, nil)
+	assert.Equal(t, "10.0.0.2:8080", f.GetAddress("user-service"))
+	assert.Equal(t, 3, r.queryCount())
+}
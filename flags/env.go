@@ -0,0 +1,165 @@
+package flags
+
+import (
+	"os"
+	"strings"
+
+	"github.com/miebyte/goutils/flags/provider"
+	"github.com/pkg/errors"
+)
+
+// envKeyReplacer maps key separators to underscores, e.g. redis.default.server -> REDIS_DEFAULT_SERVER.
+var envKeyReplacer = strings.NewReplacer(".", "_", "-", "_")
+
+// SetEnvPrefix sets the prefix of environment variables, e.g. "MYSVC" maps the key
+// redis.default.server to MYSVC_REDIS_DEFAULT_SERVER. A trailing underscore is optional.
+func (sf *SuperFlags) SetEnvPrefix(prefix string) {
+	sf.mu.Lock()
+	sf.envPrefix = strings.ToUpper(strings.TrimSuffix(prefix, "_"))
+	sf.mu.Unlock()
+}
+
+// AutomaticEnv makes every lookup check the environment variable mapped from the key.
+// Nested keys of map values (e.g. the fields of a Struct flag) are checked as well.
+func (sf *SuperFlags) AutomaticEnv() {
+	sf.mu.Lock()
+	sf.automaticEnv = true
+	sf.mu.Unlock()
+}
+
+// BindEnv binds key to the given environment variables, the first non-empty one wins.
+// Without envNames the key is bound to the variable mapped from key and the prefix.
+// Unlike AutomaticEnv, a bound nested key is applied even if it is missing from config.
+func (sf *SuperFlags) BindEnv(key string, envNames ...string) error {
+	if key == "" {
+		return errors.New("BindEnv missing key")
+	}
+
+	key = strings.ToLower(key)
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+
+	if len(envNames) == 0 {
+		envNames = []string{sf.envName(key)}
+	}
+	if sf.envBindings == nil {
+		sf.envBindings = make(map[string][]string)
+	}
+	sf.envBindings[key] = envNames
+	return nil
+}
+
+// EnvName returns the environment variable mapped from key.
+func (sf *SuperFlags) EnvName(key string) string {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return sf.envName(strings.ToLower(key))
+}
+
+// envName must be called with sf.mu held.
+func (sf *SuperFlags) envName(key string) string {
+	name := strings.ToUpper(envKeyReplacer.Replace(key))
+	if sf.envPrefix != "" {
+		name = sf.envPrefix + "_" + name
+	}
+	return name
+}
+
+// lookupEnv returns the non-empty environment value of a lower-cased key,
+// explicit bindings first. It must be called with sf.mu held.
+func (sf *SuperFlags) lookupEnv(key string) (string, bool) {
+	_, val, ok := sf.envSource(key)
+	return val, ok
+}
+
+// envSource is like lookupEnv but also returns the name of the variable that was used.
+func (sf *SuperFlags) envSource(key string) (name, val string, ok bool) {
+	for _, name := range sf.envBindings[key] {
+		if val, ok := os.LookupEnv(name); ok && val != "" {
+			return name, val, true
+		}
+	}
+
+	if sf.automaticEnv {
+		name := sf.envName(key)
+		if val, ok := os.LookupEnv(name); ok && val != "" {
+			return name, val, true
+		}
+	}
+	return "", "", false
+}
+
+// overlayEnv returns a copy of the map value of key with nested keys overridden by
+// environment variables. It must be called with sf.mu held.
+func (sf *SuperFlags) overlayEnv(key string, m map[string]any) map[string]any {
+	if !sf.automaticEnv && len(sf.envBindings) == 0 {
+		return m
+	}
+
+	nm := sf.overlayEnvLeaves(key, m)
+	for bound := range sf.envBindings {
+		rest, ok := strings.CutPrefix(bound, key+".")
+		if !ok {
+			continue
+		}
+		if val, ok := sf.lookupEnv(bound); ok {
+			provider.SetNested(nm, strings.Split(rest, "."), val)
+		}
+	}
+	return nm
+}
+
+func (sf *SuperFlags) overlayEnvLeaves(key string, m map[string]any) map[string]any {
+	nm := make(map[string]any, len(m))
+	for k, v := range m {
+		sub := key + "." + k
+		if val, ok := sf.lookupEnv(sub); ok {
+			nm[k] = val
+			continue
+		}
+		if vm, ok := v.(map[string]any); ok {
+			nm[k] = sf.overlayEnvLeaves(sub, vm)
+			continue
+		}
+		nm[k] = v
+	}
+	return nm
+}
+
+// searchPath looks up key in m, falling back to the nested path for dotted keys,
+// e.g. redis.default.server in {"redis": {"default": {"server": ...}}}.
+func searchPath(m map[string]any, key string) (any, bool) {
+	if val, ok := m[key]; ok {
+		return val, true
+	}
+	if !strings.Contains(key, ".") {
+		return nil, false
+	}
+
+	var cur any = m
+	for _, part := range strings.Split(key, ".") {
+		cm, ok := cur.(map[string]any)
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = cm[part]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// SetEnvPrefix sets the environment variable prefix of the global SuperFlags.
+func SetEnvPrefix(prefix string) {
+	sf.SetEnvPrefix(prefix)
+}
+
+// AutomaticEnv enables the environment variable layer of the global SuperFlags.
+func AutomaticEnv() {
+	sf.AutomaticEnv()
+}
+
+// BindEnv binds key of the global SuperFlags to environment variables.
+func BindEnv(key string, envNames ...string) error {
+	return sf.BindEnv(key, envNames...)
+}
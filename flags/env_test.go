@@ -0,0 +1,74 @@
+package flags
+
+import (
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEnvPrecedence(t *testing.T) {
+	s := New()
+	s.SetEnvPrefix("MYSVC_")
+	s.AutomaticEnv()
+
+	fs := pflag.NewFlagSet("test", pflag.ContinueOnError)
+	fs.String("name", "flag-default", "")
+	require.NoError(t, s.BindPFlags(fs))
+
+	s.SetDefault("name", "default")
+	assert.Equal(t, "default", s.GetString("name"))
+
+	s.ReplaceConfig(map[string]any{"name": "config"})
+	assert.Equal(t, "config", s.GetString("name"))
+
+	t.Setenv("MYSVC_NAME", "env")
+	assert.Equal(t, "env", s.GetString("name"))
+	assert.Equal(t, "env", s.AllSettings()["name"])
+
+	require.NoError(t, fs.Set("name", "flag"))
+	assert.Equal(t, "flag", s.GetString("name"))
+}
+
+func TestEnvNestedKeys(t *testing.T) {
+	s := New()
+	s.SetEnvPrefix("MYSVC")
+	s.AutomaticEnv()
+	s.ReplaceConfig(map[string]any{
+		"redis": map[string]any{
+			"default": map[string]any{"server": "127.0.0.1:6379", "db": 0},
+		},
+	})
+
+	assert.Equal(t, "MYSVC_REDIS_DEFAULT_SERVER", s.EnvName("redis.default.server"))
+	t.Setenv("MYSVC_REDIS_DEFAULT_SERVER", "redis:6379")
+
+	assert.Equal(t, "redis:6379", s.GetString("redis.default.server"))
+	assert.Equal(t, 0, s.GetInt("redis.default.db"))
+
+	redis, ok := s.Get("redis").(map[string]any)
+	require.True(t, ok)
+	assert.Equal(t, map[string]any{"server": "redis:6379", "db": 0}, redis["default"])
+
+	// the loaded config is left untouched
+	assert.Equal(t, "127.0.0.1:6379", s.config["redis"].(map[string]any)["default"].(map[string]any)["server"])
+}
+
+func TestBindEnv(t *testing.T) {
+	s := New()
+	require.Error(t, s.BindEnv(""))
+	require.NoError(t, s.BindEnv("mysql.password", "DB_PASSWORD", "MYSQL_PASSWORD"))
+	s.ReplaceConfig(map[string]any{"mysql": map[string]any{"host": "db"}})
+
+	t.Setenv("MYSQL_PASSWORD", "secret")
+	assert.Equal(t, "secret", s.GetString("mysql.password"))
+	assert.Equal(t, map[string]any{"host": "db", "password": "secret"}, s.Get("mysql"))
+
+	t.Setenv("DB_PASSWORD", "first")
+	assert.Equal(t, "first", s.GetString("mysql.password"))
+
+	// automatic env is disabled, so unbound keys are not read from env
+	t.Setenv("MYSQL_HOST", "other")
+	assert.Equal(t, "db", s.GetString("mysql.host"))
+}
@@ -10,6 +10,7 @@ package flags
 
 import (
 	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
@@ -30,12 +31,16 @@ var (
 	defaultConfigType                    = "json"
 	defaultConfigProvider ConfigProvider = nil
 
-	config StringGetter
+	config         StringGetter
+	configEnv      StringGetter
+	configOverride StringGetter
 )
 
 type Option struct {
 	UseRemote   BoolGetter
 	WatchConfig BoolGetter
+	EnvPrefix   string
+	Sources     []ConfigSource
 }
 
 type OptionFunc func(opt *Option)
@@ -52,6 +57,21 @@ func WithUseRemote() OptionFunc {
 	}
 }
 
+// WithEnvPrefix enables the environment variable layer with the given prefix,
+// e.g. WithEnvPrefix("MYSVC") maps redis.default.server to MYSVC_REDIS_DEFAULT_SERVER.
+func WithEnvPrefix(prefix string) OptionFunc {
+	return func(opt *Option) {
+		opt.EnvPrefix = prefix
+	}
+}
+
+// WithConfigSources replaces the default config sources, they are deep-merged in the given order.
+func WithConfigSources(sources ...ConfigSource) OptionFunc {
+	return func(opt *Option) {
+		opt.Sources = append(opt.Sources, sources...)
+	}
+}
+
 func GetServiceName() string {
 	return share.ServiceName()
 }
@@ -67,24 +87,7 @@ func Parse(opts ...OptionFunc) {
 	setDebugMod()
 	parseServiceName()
 
-	if opt.UseRemote() {
-		if config() != "" {
-			defaultConfigProvider = provider.NewLocalProvider(config())
-		} else {
-			checkServiceName()
-			defaultConfigProvider = provider.NewConsulProvider(share.ServiceName(), share.Tag())
-			discover.SetConsulFinder()
-		}
-	} else {
-		configPath := config()
-		if configPath == "" {
-			configPath = sf.FindConfigFile()
-			innerlog.Logger.Debugf("find local config file: %s", configPath)
-		}
-
-		defaultConfigProvider = provider.NewLocalProvider(configPath)
-	}
-
+	defaultConfigProvider = NewLayeredProvider(configSources(opt)...)
 	sf.SetConfigProvider(defaultConfigProvider)
 
 	readConfig(opt)
@@ -99,6 +102,55 @@ func Parse(opts ...OptionFunc) {
 	snail.Init()
 }
 
+// configSources builds the default config layers, lowest precedence first:
+//
+//  1. the base config file, from --configFile or found by FindConfigFile
+//  2. the environment overlay beside it, e.g. config.prod.json for --configEnv=prod
+//  3. the consul remote config, with --useRemote and without --configFile
+//  4. the local override file from --configOverride
+func configSources(opt *Option) []ConfigSource {
+	if len(opt.Sources) != 0 {
+		return opt.Sources
+	}
+
+	var sources []ConfigSource
+	localSource := func(path string) {
+		sources = append(sources, ConfigSource{
+			Name:     path,
+			Provider: provider.NewLocalProvider(path, provider.WithConfigType(sf.ConfigType())),
+		})
+	}
+
+	configPath := config()
+	useConsul := opt.UseRemote() && configPath == ""
+	if configPath == "" {
+		configPath = sf.FindConfigFile()
+		innerlog.Logger.Debugf("find local config file: %s", configPath)
+	}
+
+	if configPath != "" {
+		localSource(configPath)
+		if env := configEnv(); env != "" {
+			ext := filepath.Ext(configPath)
+			localSource(strings.TrimSuffix(configPath, ext) + "." + env + ext)
+		}
+	}
+
+	if useConsul {
+		checkServiceName()
+		sources = append(sources, ConfigSource{
+			Name:     "consul:" + share.ServiceName(),
+			Provider: provider.NewConsulProvider(share.ServiceName(), share.Tag()),
+		})
+		discover.SetConsulFinder()
+	}
+
+	if override := configOverride(); override != "" {
+		localSource(override)
+	}
+	return sources
+}
+
 func parseServiceName() {
 	serviceName := share.ServiceName()
 	segs := strings.SplitN(serviceName, ":", 2)
@@ -136,6 +188,11 @@ func initOption(opts ...OptionFunc) *Option {
 }
 
 func initSuperFlags(opt *Option) {
+	if opt.EnvPrefix != "" {
+		sf.SetEnvPrefix(opt.EnvPrefix)
+		sf.AutomaticEnv()
+	}
+
 	sf.AddConfigPath(".")
 	sf.AddConfigPath("./configs")
 	sf.AddConfigPath(os.Getenv("HOME"))
@@ -147,7 +204,9 @@ func initSuperFlags(opt *Option) {
 	share.Debug = Bool("debug", false, "Tag whether to enable debug mode.")
 	opt.UseRemote = Bool("useRemote", false, "Tag whether to use remote config")
 	opt.WatchConfig = Bool("watchConfig", false, "Tag whether to watch config")
-	config = StringP("configFile", "f", "", "Specify config file. (JSON-only)")
+	config = StringP("configFile", "f", "", "Specify config file. (json, yaml, toml or .env)")
+	configEnv = String("configEnv", "", "Merge the environment overlay beside the config file, e.g. config.prod.json for prod.")
+	configOverride = String("configOverride", "", "Specify a local config file merged over all other config sources.")
 
 	if err := sf.BindPFlags(pflag.CommandLine); err != nil {
 		innerlog.Logger.Errorf("BindPflags error: %v", err)
@@ -171,13 +230,17 @@ func watchConfig(opt *Option) {
 
 	go func() {
 		for ev := range ch {
-			innerlog.Logger.Debugf("watch config change: %s, config: %v", ev.Key, ev.Config)
-			if ev.Key == "" {
-				sf.ReplaceConfig(ev.Config)
-			} else {
-				sf.ReplaceKey(ev.Key, ev.Config)
+			paths := make(secretPaths)
+			cfg, err := resolveSecretValue(ev.Key, ev.Config, paths)
+			if err != nil {
+				innerlog.Logger.Errorf("resolve secrets of changed config failed, keep old config. err: %v", err)
+				continue
+			}
+
+			innerlog.Logger.Debugf("watch config change: %s, config: %v", ev.Key, redactValue(ev.Key, cfg, paths))
+			if _, err := applyConfigUpdate(ev.Key, cfg, paths); err != nil {
+				innerlog.Logger.Errorf("reject config update, keep previous config. err: %v", err)
 			}
-			TriggerReload(ev.Key)
 		}
 	}()
 }
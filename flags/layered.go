@@ -0,0 +1,192 @@
+package flags
+
+import (
+	"maps"
+	"strings"
+	"sync"
+
+	"github.com/miebyte/goutils/flags/provider"
+	"github.com/miebyte/goutils/internal/innerlog"
+	"github.com/pkg/errors"
+)
+
+// ConfigSource is a named ConfigProvider used as one layer of a LayeredProvider.
+type ConfigSource struct {
+	Name     string
+	Provider ConfigProvider
+}
+
+// ConfigSourcer is implemented by providers that track which source supplied each key.
+type ConfigSourcer interface {
+	Source(key string) (string, bool)
+}
+
+// LayeredProvider deep-merges the configs of several sources in declared order,
+// later sources overriding earlier ones. Nested maps are merged key by key,
+// any other value (including slices) is replaced as a whole.
+type LayeredProvider struct {
+	sources []ConfigSource
+
+	mu         sync.RWMutex
+	layers     []map[string]any
+	provenance map[string]string
+}
+
+func NewLayeredProvider(sources ...ConfigSource) *LayeredProvider {
+	return &LayeredProvider{
+		sources:    sources,
+		layers:     make([]map[string]any, len(sources)),
+		provenance: make(map[string]string),
+	}
+}
+
+// ReadConfig reads every source and returns the merged config.
+// A source returning nil config (e.g. a missing local file) is skipped.
+func (p *LayeredProvider) ReadConfig() (map[string]any, error) {
+	layers := make([]map[string]any, len(p.sources))
+	for i, src := range p.sources {
+		cfg, err := src.Provider.ReadConfig()
+		if err != nil {
+			return nil, errors.Wrapf(err, "read config source(%s)", src.Name)
+		}
+		if cfg != nil {
+			layers[i] = copyAndInsensitiviseMap(cfg)
+			innerlog.Logger.Debugf("config source(%s) loaded, keys: %d", src.Name, len(cfg))
+		}
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.layers = layers
+	return p.merge(), nil
+}
+
+// WatchConfig watches every source and emits the re-merged config whenever one of them changes.
+// Emitted events carry an empty Key, meaning the whole config is replaced.
+func (p *LayeredProvider) WatchConfig() <-chan provider.Event {
+	out := make(chan provider.Event)
+	// events of all sources are applied and emitted by a single goroutine, so the
+	// merged configs are sent in the order they are computed
+	updates := make(chan layerUpdate)
+
+	var wg sync.WaitGroup
+	for i, src := range p.sources {
+		ch := src.Provider.WatchConfig()
+		if ch == nil {
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ev := range ch {
+				updates <- layerUpdate{index: i, name: src.Name, event: ev}
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(updates)
+	}()
+
+	go func() {
+		defer close(out)
+		for u := range updates {
+			if u.event.Err != nil {
+				innerlog.Logger.Errorf("Watch config source(%s) error, keep old config. err: %v", u.name, u.event.Err)
+				continue
+			}
+			if u.event.Config == nil {
+				continue
+			}
+
+			p.mu.Lock()
+			p.layers[u.index] = copyAndInsensitiviseMap(u.event.Config)
+			merged := p.merge()
+			p.mu.Unlock()
+
+			innerlog.Logger.Debugf("config source(%s) changed", u.name)
+			out <- provider.Event{Path: u.event.Path, Config: merged}
+		}
+	}()
+	return out
+}
+
+type layerUpdate struct {
+	index int
+	name  string
+	event provider.Event
+}
+
+// Source returns the name of the source that supplied key, keys of nested maps
+// are separated by dots. For a map value, it is the last source that contributed to it.
+func (p *LayeredProvider) Source(key string) (string, bool) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+
+	name, ok := p.provenance[key]
+	return name, ok
+}
+
+// Provenance returns a copy of the key to source name mapping of the merged config.
+func (p *LayeredProvider) Provenance() map[string]string {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return maps.Clone(p.provenance)
+}
+
+// merge must be called with p.mu held.
+func (p *LayeredProvider) merge() map[string]any {
+	merged := make(map[string]any)
+	provenance := make(map[string]string)
+	for i, layer := range p.layers {
+		if layer != nil {
+			mergeLayer(merged, layer, "", p.sources[i].Name, provenance)
+		}
+	}
+	p.provenance = provenance
+	return merged
+}
+
+// mergeLayer deep-merges src into dst, recording the source of every merged key.
+func mergeLayer(dst, src map[string]any, prefix, source string, provenance map[string]string) {
+	for key, val := range src {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+		provenance[path] = source
+
+		dm, dstIsMap := dst[key].(map[string]any)
+		sm, ok := val.(map[string]any)
+		if !ok {
+			if dstIsMap {
+				forgetNested(provenance, path)
+			}
+			dst[key] = val
+			continue
+		}
+
+		if !dstIsMap {
+			dm = make(map[string]any, len(sm))
+			dst[key] = dm
+		}
+		mergeLayer(dm, sm, path, source, provenance)
+	}
+}
+
+// forgetNested drops the provenance of keys under path once the value at path is replaced.
+func forgetNested(provenance map[string]string, path string) {
+	prefix := path + "."
+	for key := range provenance {
+		if strings.HasPrefix(key, prefix) {
+			delete(provenance, key)
+		}
+	}
+}
+
+// Source reports which layer supplies the effective value of key in the global SuperFlags.
+func Source(key string) string {
+	return sf.Source(key)
+}
@@ -0,0 +1,156 @@
+package flags
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/miebyte/goutils/flags/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeConfig(t *testing.T, path, content string) {
+	t.Helper()
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o644))
+}
+
+func TestLayeredProviderMerge(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "config.json")
+	overlay := filepath.Join(dir, "config.prod.yaml")
+	writeConfig(t, base, `{"name": "svc", "mysql": {"host": "localhost", "port": 3306}, "tags": ["a", "b"]}`)
+	writeConfig(t, overlay, "mysql:\n  host: db.prod\ntags: [c]\n")
+
+	p := NewLayeredProvider(
+		ConfigSource{Name: "base", Provider: provider.NewLocalProvider(base)},
+		ConfigSource{Name: "prod", Provider: provider.NewLocalProvider(overlay)},
+		ConfigSource{Name: "missing", Provider: provider.NewLocalProvider(filepath.Join(dir, "none.json"))},
+	)
+	cfg, err := p.ReadConfig()
+	require.NoError(t, err)
+
+	assert.Equal(t, "svc", cfg["name"])
+	assert.Equal(t, map[string]any{"host": "db.prod", "port": float64(3306)}, cfg["mysql"])
+	assert.Equal(t, []any{"c"}, cfg["tags"])
+
+	for key, source := range map[string]string{
+		"name":       "base",
+		"mysql":      "prod",
+		"mysql.host": "prod",
+		"mysql.port": "base",
+		"tags":       "prod",
+	} {
+		got, ok := p.Source(key)
+		assert.True(t, ok, key)
+		assert.Equal(t, source, got, key)
+	}
+	_, ok := p.Source("unknown")
+	assert.False(t, ok)
+}
+
+func TestLayeredProviderScalarReplacesMap(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.json")
+	override := filepath.Join(dir, "override.json")
+	writeConfig(t, base, `{"cache": {"size": 10}}`)
+	writeConfig(t, override, `{"cache": "disabled"}`)
+
+	p := NewLayeredProvider(
+		ConfigSource{Name: "base", Provider: provider.NewLocalProvider(base)},
+		ConfigSource{Name: "override", Provider: provider.NewLocalProvider(override)},
+	)
+	cfg, err := p.ReadConfig()
+	require.NoError(t, err)
+
+	assert.Equal(t, "disabled", cfg["cache"])
+	assert.Equal(t, map[string]string{"cache": "override"}, p.Provenance())
+}
+
+func TestLayeredProviderWatch(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "base.json")
+	override := filepath.Join(dir, "override.json")
+	writeConfig(t, base, `{"name": "svc", "level": "info"}`)
+
+	p := NewLayeredProvider(
+		ConfigSource{Name: "base", Provider: provider.NewLocalProvider(base)},
+		ConfigSource{Name: "override", Provider: provider.NewLocalProvider(override)},
+	)
+	_, err := p.ReadConfig()
+	require.NoError(t, err)
+
+	ch := p.WatchConfig()
+	writeConfig(t, override, `{"level": "debug"}`)
+
+	select {
+	case ev := <-ch:
+		assert.Empty(t, ev.Key)
+		assert.Equal(t, map[string]any{"name": "svc", "level": "debug"}, ev.Config)
+	case <-time.After(time.Second * 5):
+		t.Fatal("no config event")
+	}
+
+	source, _ := p.Source("level")
+	assert.Equal(t, "override", source)
+}
+
+// chanProvider emits the events sent to it
+type chanProvider chan provider.Event
+
+func (c chanProvider) ReadConfig() (map[string]any, error) {
+	return nil, nil
+}
+
+func (c chanProvider) WatchConfig() <-chan provider.Event {
+	return c
+}
+
+func TestLayeredProviderWatchOrdered(t *testing.T) {
+	a, b := make(chanProvider), make(chanProvider)
+	p := NewLayeredProvider(
+		ConfigSource{Name: "a", Provider: a},
+		ConfigSource{Name: "b", Provider: b},
+	)
+	ch := p.WatchConfig()
+
+	const n = 100
+	for _, src := range []struct {
+		key string
+		ch  chanProvider
+	}{{"a", a}, {"b", b}} {
+		go func() {
+			defer close(src.ch)
+			for i := 1; i <= n; i++ {
+				src.ch <- provider.Event{Config: map[string]any{src.key: i}}
+			}
+		}()
+	}
+
+	// the last emitted config reflects the latest update of every source
+	var last map[string]any
+	for ev := range ch {
+		last = ev.Config
+	}
+	assert.Equal(t, map[string]any{"a": n, "b": n}, last)
+}
+
+func TestSuperFlagsSource(t *testing.T) {
+	dir := t.TempDir()
+	base := filepath.Join(dir, "config.json")
+	writeConfig(t, base, `{"name": "svc"}`)
+
+	s := New()
+	s.SetDefault("port", 8080)
+	s.SetConfigProvider(NewLayeredProvider(ConfigSource{Name: base, Provider: provider.NewLocalProvider(base)}))
+	require.NoError(t, s.ReadConfig())
+
+	assert.Equal(t, base, s.Source("name"))
+	assert.Equal(t, "default", s.Source("port"))
+	assert.Equal(t, "", s.Source("unknown"))
+
+	require.NoError(t, s.BindEnv("name", "TEST_SVC_NAME"))
+	t.Setenv("TEST_SVC_NAME", "env")
+	assert.Equal(t, "env:TEST_SVC_NAME", s.Source("name"))
+}
@@ -1,8 +1,10 @@
 package provider
 
 import (
-	"encoding/json"
+	"context"
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/Masterminds/semver"
 	"github.com/hashicorp/consul/api"
@@ -46,29 +48,29 @@ func (p *ConsulProvider) listPossibleTags(tag string) []string {
 	return possibleTags
 }
 
+// getRemotePossiblePath returns the first existing key among {tag}.{ext} for every
+// possible tag, trying the extensions in SupportedExts order.
 func (p *ConsulProvider) getRemotePossiblePath(name, tag string) string {
 	var possiblePath string
 	defer func() {
 		innerlog.Logger.Infof("Reading consul config from possiblePath(%s)", possiblePath)
 	}()
 
-	kv := consulutils.GetConsulClient().KV()
-	if tag == "dev" {
-		path := fmt.Sprintf("%s/%s.json", p.getServerKey(name), tag)
-		pair, _, err := kv.Get(path, nil)
-		if err == nil && pair != nil {
-			possiblePath = path
-			return possiblePath
-		}
+	prefix := p.getServerKey(name)
+	keys, _, err := consulutils.GetConsulClient().KV().Keys(prefix+"/", "", nil)
+	if err != nil {
+		innerlog.Logger.Errorf("List consul config keys of %s failed. err: %v", prefix, err)
+		return ""
 	}
 
 	for _, t := range p.listPossibleTags(tag) {
-		path := fmt.Sprintf("%s/%s.json", p.getServerKey(name), t)
-
-		pair, _, err := kv.Get(path, nil)
-		if err == nil && pair != nil {
-			possiblePath = path
-			break
+		for _, ext := range SupportedExts {
+			path := fmt.Sprintf("%s/%s.%s", prefix, t, ext)
+			// keys listed by consul have no leading slash
+			if slices.Contains(keys, strings.TrimPrefix(path, "/")) {
+				possiblePath = path
+				return possiblePath
+			}
 		}
 	}
 
@@ -83,22 +85,12 @@ func (p *ConsulProvider) ReadConfig() (map[string]any, error) {
 		return nil, fmt.Errorf("no config found")
 	}
 
-	kv := consulutils.GetConsulClient().KV()
-	pair, _, err := kv.Get(path, nil)
+	entry, err := consulutils.GetKV[[]byte](context.Background(), consulutils.GetConsulClient(), path)
 	if err != nil {
 		return nil, err
 	}
 
-	if pair == nil {
-		return nil, fmt.Errorf("no config found")
-	}
-
-	temp := make(map[string]any)
-	if err := json.Unmarshal(pair.Value, &temp); err != nil {
-		return nil, err
-	}
-
-	return temp, nil
+	return Decode(FormatFromPath(path), entry.Value)
 }
 
 func (p *ConsulProvider) WatchConfig() <-chan Event {
@@ -129,8 +121,8 @@ func (p *ConsulProvider) WatchConfig() <-chan Event {
 		}
 		innerlog.Logger.Debugf("Remote config changed")
 
-		temp := make(map[string]any)
-		if err := json.Unmarshal(kv.Value, &temp); err != nil {
+		temp, err := Decode(FormatFromPath(key), kv.Value)
+		if err != nil {
 			innerlog.Logger.Errorf("Failed to unmarshal remote config data. err=%v", err)
 			return
 		}
@@ -0,0 +1,141 @@
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/pelletier/go-toml/v2"
+	"github.com/pkg/errors"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	FormatJSON = "json"
+	FormatYAML = "yaml"
+	FormatTOML = "toml"
+	FormatEnv  = "env"
+)
+
+// SupportedExts lists the config file extensions that can be decoded, in lookup order.
+var SupportedExts = []string{"json", "yaml", "yml", "toml", "env"}
+
+// envNestedSep separates nested keys in .env files, e.g. MYSQL__HOST maps to mysql.host.
+const envNestedSep = "__"
+
+// FormatFromPath detects the config format from the extension of path.
+// Files named ".env" are treated as env files. It returns "" when the extension is unknown.
+func FormatFromPath(path string) string {
+	base := filepath.Base(path)
+	if base == ".env" {
+		return FormatEnv
+	}
+	return normalizeFormat(strings.TrimPrefix(filepath.Ext(base), "."))
+}
+
+func normalizeFormat(format string) string {
+	switch strings.ToLower(format) {
+	case "json":
+		return FormatJSON
+	case "yaml", "yml":
+		return FormatYAML
+	case "toml":
+		return FormatTOML
+	case "env", "dotenv":
+		return FormatEnv
+	}
+	return ""
+}
+
+// Decode decodes b according to format into a lower-cased nested map.
+// An empty format is treated as JSON.
+func Decode(format string, b []byte) (map[string]any, error) {
+	temp := make(map[string]any)
+
+	var err error
+	switch f := normalizeFormat(format); {
+	case f == FormatYAML:
+		err = yaml.Unmarshal(b, &temp)
+	case f == FormatTOML:
+		err = toml.Unmarshal(b, &temp)
+	case f == FormatEnv:
+		temp, err = decodeEnv(b)
+	case f == FormatJSON || format == "":
+		err = json.Unmarshal(b, &temp)
+	default:
+		return nil, errors.Errorf("unsupported config format %q", format)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "decode %s config", format)
+	}
+
+	return normalizeMapCaseInsensitive(temp), nil
+}
+
+// decodeEnv parses KEY=VALUE lines. Blank lines and lines starting with # are ignored,
+// an optional "export " prefix is allowed, and values may be single or double quoted.
+func decodeEnv(b []byte) (map[string]any, error) {
+	m := make(map[string]any)
+
+	scanner := bufio.NewScanner(bytes.NewReader(b))
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" {
+			return nil, errors.Errorf("line %d: invalid env line %q", lineNo, line)
+		}
+
+		value, err := parseEnvValue(strings.TrimSpace(value))
+		if err != nil {
+			return nil, errors.Wrapf(err, "line %d", lineNo)
+		}
+		SetNested(m, strings.Split(strings.ToLower(key), envNestedSep), value)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func parseEnvValue(value string) (string, error) {
+	if len(value) >= 2 {
+		switch value[0] {
+		case '"':
+			if value[len(value)-1] == '"' {
+				return strconv.Unquote(value)
+			}
+		case '\'':
+			if value[len(value)-1] == '\'' {
+				return value[1 : len(value)-1], nil
+			}
+		}
+	}
+
+	// strip trailing inline comments of unquoted values
+	if i := strings.Index(value, " #"); i >= 0 {
+		value = strings.TrimSpace(value[:i])
+	}
+	return value, nil
+}
+
+// SetNested sets value at the nested path, creating intermediate maps as needed.
+func SetNested(m map[string]any, path []string, value any) {
+	for _, key := range path[:len(path)-1] {
+		sub, ok := m[key].(map[string]any)
+		if !ok {
+			sub = make(map[string]any)
+			m[key] = sub
+		}
+		m = sub
+	}
+	m[path[len(path)-1]] = value
+}
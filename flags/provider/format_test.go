@@ -0,0 +1,80 @@
+package provider
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/spf13/cast"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFormatFromPath(t *testing.T) {
+	assert.Equal(t, FormatJSON, FormatFromPath("config.json"))
+	assert.Equal(t, FormatYAML, FormatFromPath("/etc/configs/svc/dev.yml"))
+	assert.Equal(t, FormatTOML, FormatFromPath("config.TOML"))
+	assert.Equal(t, FormatEnv, FormatFromPath("/app/.env"))
+	assert.Equal(t, "", FormatFromPath("config"))
+}
+
+func TestDecode(t *testing.T) {
+	cases := map[string]string{
+		FormatJSON: `{"Name": "svc", "MySQL": {"Host": "127.0.0.1", "Port": 3306}}`,
+		FormatYAML: "Name: svc\nMySQL:\n  Host: 127.0.0.1\n  Port: 3306\n",
+		FormatTOML: "Name = \"svc\"\n[MySQL]\nHost = \"127.0.0.1\"\nPort = 3306\n",
+		FormatEnv:  "# comment\nexport NAME=svc\nMYSQL__HOST=\"127.0.0.1\"\nMYSQL__PORT=3306 # inline\n",
+	}
+
+	for format, content := range cases {
+		t.Run(format, func(t *testing.T) {
+			m, err := Decode(format, []byte(content))
+			require.NoError(t, err)
+
+			assert.Equal(t, "svc", m["name"])
+			mysql, ok := m["mysql"].(map[string]any)
+			require.True(t, ok)
+			assert.Equal(t, "127.0.0.1", mysql["host"])
+			assert.Equal(t, 3306, cast.ToInt(mysql["port"]))
+		})
+	}
+}
+
+func TestDecodeInvalid(t *testing.T) {
+	_, err := Decode(FormatEnv, []byte("NOVALUE"))
+	assert.Error(t, err)
+
+	_, err = Decode("xml", []byte("<a/>"))
+	assert.Error(t, err)
+}
+
+func TestLocalProviderConfigType(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+	require.NoError(t, os.WriteFile(path, []byte("name: svc\n"), 0o644))
+
+	m, err := NewLocalProvider(path, WithConfigType("yaml")).ReadConfig()
+	require.NoError(t, err)
+	assert.Equal(t, "svc", m["name"])
+}
+
+func TestLocalProviderWatchSkipEmpty(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	require.NoError(t, os.WriteFile(path, []byte("name: a\n"), 0o644))
+
+	ch := NewLocalProvider(path).WatchConfig()
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_TRUNC, 0o644)
+	require.NoError(t, err)
+	time.Sleep(time.Millisecond * 50)
+	_, err = f.WriteString("name: b\n")
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+
+	select {
+	case ev := <-ch:
+		require.NoError(t, ev.Err)
+		assert.Equal(t, "b", ev.Config["name"])
+	case <-time.After(time.Second * 2):
+		t.Fatal("no config event")
+	}
+}
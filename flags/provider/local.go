@@ -1,7 +1,7 @@
 package provider
 
 import (
-	"encoding/json"
+	"bytes"
 	"os"
 	"path/filepath"
 
@@ -11,11 +11,34 @@ import (
 )
 
 type LocalProvider struct {
-	filePath string
+	filePath   string
+	configType string
 }
 
-func NewLocalProvider(filePath string) *LocalProvider {
-	return &LocalProvider{filePath: filePath}
+type LocalOption func(*LocalProvider)
+
+// WithConfigType sets the format used when the file extension is not recognized,
+// e.g. "yaml" for a config file without extension.
+func WithConfigType(configType string) LocalOption {
+	return func(l *LocalProvider) {
+		l.configType = configType
+	}
+}
+
+func NewLocalProvider(filePath string, opts ...LocalOption) *LocalProvider {
+	l := &LocalProvider{filePath: filePath}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// format detects the format by file extension first, then falls back to configType.
+func (l *LocalProvider) format() string {
+	if f := FormatFromPath(l.filePath); f != "" {
+		return f
+	}
+	return l.configType
 }
 
 func (l *LocalProvider) fileExists(filePath string) bool {
@@ -29,6 +52,15 @@ func (l *LocalProvider) fileExists(filePath string) bool {
 }
 
 func (l *LocalProvider) ReadConfig() (map[string]any, error) {
+	b, err := l.readFile()
+	if err != nil || b == nil {
+		return nil, err
+	}
+	return l.decode(b)
+}
+
+// readFile returns nil without error when the file does not exist.
+func (l *LocalProvider) readFile() ([]byte, error) {
 	if !l.fileExists(l.filePath) {
 		return nil, nil
 	}
@@ -37,15 +69,18 @@ func (l *LocalProvider) ReadConfig() (map[string]any, error) {
 	if err != nil {
 		return nil, errors.Wrap(err, "readFile")
 	}
+	return b, nil
+}
 
-	temp := make(map[string]any)
-	if err := json.Unmarshal(b, &temp); err != nil {
+func (l *LocalProvider) decode(b []byte) (map[string]any, error) {
+	// keys are normalized to lower-case, recursively
+	temp, err := Decode(l.format(), b)
+	if err != nil {
 		return nil, errors.Wrap(err, "unmarshalLocalConfig")
 	}
 
 	innerlog.Logger.Infof("Read local config success. Config=%s", l.filePath)
-	// normalize keys to lower-case, recursively
-	return normalizeMapCaseInsensitive(temp), nil
+	return temp, nil
 }
 
 func (l *LocalProvider) WatchConfig() <-chan Event {
@@ -80,8 +115,15 @@ func (l *LocalProvider) WatchConfig() <-chan Event {
 			case ev := <-w.Events:
 				if ev.Name == l.filePath && (ev.Op&fsnotify.Write == fsnotify.Write || ev.Op&fsnotify.Create == fsnotify.Create) {
 					innerlog.Logger.Debugf("local config change: %s", ev.Name)
-					event := Event{Path: l.filePath}
-					event.Config, event.Err = l.ReadConfig()
+					b, err := l.readFile()
+					// a truncated file being rewritten is empty, wait for the next write
+					if err == nil && b != nil && len(bytes.TrimSpace(b)) == 0 {
+						continue
+					}
+					event := Event{Path: l.filePath, Err: err}
+					if err == nil && b != nil {
+						event.Config, event.Err = l.decode(b)
+					}
 					ch <- event
 				}
 			case err := <-w.Errors:
@@ -0,0 +1,99 @@
+package flags
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+const (
+	RedactedValue = "******"
+)
+
+var (
+	secretKeyMu    sync.RWMutex
+	secretKeyParts = []string{"password", "passwd", "secret", "token", "credential", "apikey", "api_key", "accesskey", "access_key", "privatekey", "private_key"}
+)
+
+// RegisterSecretKeys adds key fragments to redact, a key containing any fragment (case-insensitive) is treated as secret.
+func RegisterSecretKeys(parts ...string) {
+	secretKeyMu.Lock()
+	defer secretKeyMu.Unlock()
+
+	for _, part := range parts {
+		part = strings.ToLower(part)
+		if part != "" && !slices.Contains(secretKeyParts, part) {
+			secretKeyParts = append(secretKeyParts, part)
+		}
+	}
+}
+
+// IsSecretKey reports whether the key is a secret config key.
+func IsSecretKey(key string) bool {
+	key = strings.ToLower(key)
+
+	secretKeyMu.RLock()
+	defer secretKeyMu.RUnlock()
+
+	for _, part := range secretKeyParts {
+		if strings.Contains(key, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// Redact returns a copy of settings with RedactedValue in place of secret keys, including keys of nested maps,
+// and of values resolved from secret references by the global SuperFlags.
+func Redact(settings map[string]any) map[string]any {
+	return sf.Redact(settings)
+}
+
+// Redact returns a copy of settings with RedactedValue in place of secret keys, including keys of nested maps,
+// and of values resolved from secret references in the latest load.
+func (sf *SuperFlags) Redact(settings map[string]any) map[string]any {
+	return redactMap("", settings, sf.getSecretPaths())
+}
+
+func redactMap(path string, settings map[string]any, secrets secretPaths) map[string]any {
+	redacted := make(map[string]any, len(settings))
+	for key, val := range settings {
+		if IsSecretKey(key) {
+			redacted[key] = RedactedValue
+			continue
+		}
+		redacted[key] = redactValue(joinPath(path, key), val, secrets)
+	}
+	return redacted
+}
+
+func redactValue(path string, val any, secrets secretPaths) any {
+	if path != "" && secrets.has(path) {
+		return RedactedValue
+	}
+
+	switch v := val.(type) {
+	case map[string]any:
+		return redactMap(path, v, secrets)
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = redactValue(joinPath(path, strconv.Itoa(i)), item, secrets)
+		}
+		return out
+	default:
+		return v
+	}
+}
+
+// AllSettings returns all the settings currently in effect.
+func AllSettings() map[string]any {
+	return sf.AllSettings()
+}
+
+// AllSettingsRedacted returns all the settings currently in effect with secrets redacted,
+// which is safe for logs and debug endpoints.
+func AllSettingsRedacted() map[string]any {
+	return sf.Redact(sf.AllSettings())
+}
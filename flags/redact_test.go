@@ -0,0 +1,41 @@
+package flags
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRedact(t *testing.T) {
+	settings := map[string]any{
+		"addr":     ":8080",
+		"password": "p@ss",
+		"mysql": map[string]any{
+			"user":   "root",
+			"passwd": "root",
+		},
+		"clients": []any{
+			map[string]any{"name": "a", "apitoken": "xxx"},
+		},
+	}
+
+	redacted := Redact(settings)
+	assert.Equal(t, ":8080", redacted["addr"])
+	assert.Equal(t, RedactedValue, redacted["password"])
+	assert.Equal(t, "root", redacted["mysql"].(map[string]any)["user"])
+	assert.Equal(t, RedactedValue, redacted["mysql"].(map[string]any)["passwd"])
+	assert.Equal(t, RedactedValue, redacted["clients"].([]any)[0].(map[string]any)["apitoken"])
+	assert.Equal(t, "p@ss", settings["password"])
+}
+
+func TestAllSettings(t *testing.T) {
+	s := New()
+	s.SetDefault("name", "default")
+	s.SetDefault("port", 80)
+	s.ReplaceConfig(map[string]any{"Port": 8080, "Token": "abc"})
+
+	all := s.AllSettings()
+	assert.Equal(t, "default", all["name"])
+	assert.Equal(t, 8080, all["port"])
+	assert.Equal(t, RedactedValue, Redact(all)["token"])
+}
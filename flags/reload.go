@@ -1,8 +1,19 @@
 package flags
 
-import "github.com/miebyte/goutils/internal/innerlog"
+import (
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/miebyte/goutils/internal/innerlog"
+	"github.com/pkg/errors"
+)
 
 var (
+	keyStructMu  sync.RWMutex
 	keyStructMap = make(map[string]ConfigReloadHook)
 )
 
@@ -11,6 +22,9 @@ type ConfigReloadHook interface {
 }
 
 func RegisterReloadFunc(key string, r ConfigReloadHook) {
+	keyStructMu.Lock()
+	defer keyStructMu.Unlock()
+
 	if _, exists := keyStructMap[key]; exists {
 		innerlog.Logger.Infof("reload struct: %s has been registered", key)
 		return
@@ -19,8 +33,16 @@ func RegisterReloadFunc(key string, r ConfigReloadHook) {
 	keyStructMap[key] = r
 }
 
+func getReloadHooks() map[string]ConfigReloadHook {
+	keyStructMu.RLock()
+	defer keyStructMu.RUnlock()
+	return maps.Clone(keyStructMap)
+}
+
 func doConfigHook(key string) {
+	keyStructMu.RLock()
 	rh, exists := keyStructMap[key]
+	keyStructMu.RUnlock()
 	if !exists {
 		return
 	}
@@ -29,8 +51,8 @@ func doConfigHook(key string) {
 }
 
 func TriggerReloadAll() {
-	for key := range keyStructMap {
-		doConfigHook(key)
+	for _, hook := range getReloadHooks() {
+		hook.Reload()
 	}
 }
 
@@ -43,3 +65,191 @@ func TriggerReload(key string) {
 
 	doConfigHook(key)
 }
+
+var (
+	schemaMu sync.RWMutex
+	// schemaMap records the type of every Struct key, used to validate config updates before they go live.
+	schemaMap = make(map[string]reflect.Type)
+
+	changeHookMu sync.RWMutex
+	changeHooks  []ConfigChangeHook
+)
+
+// ConfigChangeHook is called with the diff of every config update that went live.
+type ConfigChangeHook func(diff ConfigDiff)
+
+// OnConfigChange registers a hook called after every accepted config update,
+// once the reload hooks of the changed keys have run.
+func OnConfigChange(hook ConfigChangeHook) {
+	changeHookMu.Lock()
+	changeHooks = append(changeHooks, hook)
+	changeHookMu.Unlock()
+}
+
+func registerSchema(key string, typ reflect.Type) {
+	if typ == nil {
+		return
+	}
+
+	schemaMu.Lock()
+	schemaMap[strings.ToLower(key)] = typ
+	schemaMu.Unlock()
+}
+
+// ConfigDiff lists the keys changed by a config update. Nested keys are joined by dots
+// and only leaf values are compared, so a changed mysql.host is not reported as mysql.
+type ConfigDiff struct {
+	Added   []string
+	Removed []string
+	Changed []string
+}
+
+func (d ConfigDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// Touches reports whether key, any key nested in it, or any of its parents changed.
+func (d ConfigDiff) Touches(key string) bool {
+	key = strings.ToLower(key)
+	for _, keys := range [][]string{d.Added, d.Removed, d.Changed} {
+		for _, k := range keys {
+			if k == key || strings.HasPrefix(k, key+".") || strings.HasPrefix(key, k+".") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func (d ConfigDiff) String() string {
+	return fmt.Sprintf("added=%v removed=%v changed=%v", d.Added, d.Removed, d.Changed)
+}
+
+func diffConfig(old, new map[string]any) ConfigDiff {
+	oldLeaves := make(map[string]any)
+	newLeaves := make(map[string]any)
+	flattenConfig(oldLeaves, "", old)
+	flattenConfig(newLeaves, "", new)
+
+	var diff ConfigDiff
+	for key, nv := range newLeaves {
+		ov, ok := oldLeaves[key]
+		switch {
+		case !ok:
+			diff.Added = append(diff.Added, key)
+		case !reflect.DeepEqual(ov, nv):
+			diff.Changed = append(diff.Changed, key)
+		}
+	}
+	for key := range oldLeaves {
+		if _, ok := newLeaves[key]; !ok {
+			diff.Removed = append(diff.Removed, key)
+		}
+	}
+
+	slices.Sort(diff.Added)
+	slices.Sort(diff.Removed)
+	slices.Sort(diff.Changed)
+	return diff
+}
+
+func flattenConfig(leaves map[string]any, prefix string, m map[string]any) {
+	for key, val := range m {
+		if prefix != "" {
+			key = prefix + "." + key
+		}
+		if sub, ok := val.(map[string]any); ok && len(sub) != 0 {
+			flattenConfig(leaves, key, sub)
+			continue
+		}
+		leaves[key] = val
+	}
+}
+
+// validateConfig decodes every Struct key of candidate into a new value of its
+// registered type and runs the HasDefault and HasValidator checks.
+func validateConfig(candidate *SuperFlags) error {
+	schemaMu.RLock()
+	schemas := maps.Clone(schemaMap)
+	schemaMu.RUnlock()
+
+	var msgs []string
+	for key, typ := range schemas {
+		// keys missing from the candidate config are checked when they are parsed
+		if _, ok := searchPath(candidate.config, key); !ok {
+			continue
+		}
+		if typ.Kind() == reflect.Pointer {
+			typ = typ.Elem()
+		}
+
+		out := reflect.New(typ).Interface()
+		if err := candidate.unmarshalKey(key, out); err != nil {
+			msgs = append(msgs, fmt.Sprintf("%s: %v", key, err))
+			continue
+		}
+		if err := structCheck(out); err != nil {
+			msgs = append(msgs, fmt.Sprintf("%s: %v", key, err))
+		}
+	}
+	if len(msgs) != 0 {
+		slices.Sort(msgs)
+		return errors.Errorf("invalid config: %s", strings.Join(msgs, "; "))
+	}
+	return nil
+}
+
+// applyConfigUpdate runs the reload pipeline for a watched change: the update is
+// validated against the registered structs before going live, and rejected as a
+// whole if any check fails, keeping the previous config. An empty key replaces
+// the whole config. paths are the secret key paths resolved in value, recorded
+// together with the swap. On success the reload hooks of the changed keys and the
+// OnConfigChange hooks receive the diff.
+func applyConfigUpdate(key string, value any, paths secretPaths) (ConfigDiff, error) {
+	diff, ok, err := sf.swapConfig(key, value, paths)
+	if err != nil || !ok {
+		return ConfigDiff{}, err
+	}
+
+	if diff.Empty() {
+		innerlog.Logger.Debugf("config reloaded without changes")
+		return diff, nil
+	}
+	innerlog.Logger.Infof("config changed: %s", diff)
+
+	for key, hook := range getReloadHooks() {
+		if diff.Touches(key) {
+			hook.Reload()
+		}
+	}
+
+	changeHookMu.RLock()
+	hooks := slices.Clone(changeHooks)
+	changeHookMu.RUnlock()
+	for _, hook := range hooks {
+		hook(diff)
+	}
+	return diff, nil
+}
+
+// swapConfig builds, validates and swaps in the candidate config under one lock,
+// so a concurrent ReplaceKey or Set is never lost. Validators of the registered
+// structs run with the lock held and must not read flags. The secret paths are
+// recorded in the same critical section, so new secrets are never visible unredacted.
+func (sf *SuperFlags) swapConfig(key string, value any, paths secretPaths) (ConfigDiff, bool, error) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+
+	candidate, ok := sf.candidateConfig(key, value)
+	if !ok {
+		return ConfigDiff{}, false, nil
+	}
+	if err := validateConfig(sf.withConfig(candidate)); err != nil {
+		return ConfigDiff{}, false, err
+	}
+
+	diff := diffConfig(sf.config, candidate)
+	sf.setSecretPathsLocked(key, paths)
+	sf.config = candidate
+	return diff, true, nil
+}
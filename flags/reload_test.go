@@ -0,0 +1,163 @@
+package flags
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type reloadConf struct {
+	Addr     string `json:"addr"`
+	Workers  int    `json:"workers"`
+	reloaded int
+}
+
+func (c *reloadConf) Validate() error {
+	if c.Workers <= 0 {
+		return errors.New("workers must be positive")
+	}
+	return nil
+}
+
+func (c *reloadConf) Reload() {
+	c.reloaded++
+}
+
+func TestDiffConfig(t *testing.T) {
+	diff := diffConfig(
+		map[string]any{"name": "svc", "mysql": map[string]any{"host": "a", "port": 3306}, "tags": []any{"x"}},
+		map[string]any{"name": "svc", "mysql": map[string]any{"host": "b"}, "tags": []any{"x", "y"}, "debug": true},
+	)
+
+	assert.Equal(t, []string{"debug"}, diff.Added)
+	assert.Equal(t, []string{"mysql.port"}, diff.Removed)
+	assert.Equal(t, []string{"mysql.host", "tags"}, diff.Changed)
+
+	assert.True(t, diff.Touches("mysql"))
+	assert.True(t, diff.Touches("MySQL.Host"))
+	assert.False(t, diff.Touches("name"))
+	assert.True(t, diffConfig(map[string]any{"a": 1}, map[string]any{"a": 1}).Empty())
+}
+
+// isolateGlobalFlags restores the config, schemas and hooks of the package-level
+// SuperFlags when the test ends.
+func isolateGlobalFlags(t *testing.T) {
+	t.Helper()
+
+	sf.mu.RLock()
+	config, defaults, secrets := maps.Clone(sf.config), maps.Clone(sf.defaults), maps.Clone(sf.secretPaths)
+	sf.mu.RUnlock()
+	schemaMu.RLock()
+	schemas := maps.Clone(schemaMap)
+	schemaMu.RUnlock()
+	reloadHooks := getReloadHooks()
+	changeHookMu.RLock()
+	hooks := slices.Clone(changeHooks)
+	changeHookMu.RUnlock()
+
+	t.Cleanup(func() {
+		sf.mu.Lock()
+		sf.config, sf.defaults, sf.secretPaths = config, defaults, secrets
+		sf.mu.Unlock()
+		schemaMu.Lock()
+		schemaMap = schemas
+		schemaMu.Unlock()
+		keyStructMu.Lock()
+		keyStructMap = reloadHooks
+		keyStructMu.Unlock()
+		changeHookMu.Lock()
+		changeHooks = hooks
+		changeHookMu.Unlock()
+	})
+}
+
+func TestApplyConfigUpdate(t *testing.T) {
+	isolateGlobalFlags(t)
+
+	parse := Struct("reloadConf", (*reloadConf)(nil), "reload config")
+	sf.ReplaceKey("reloadConf", map[string]any{"addr": ":80", "workers": 1})
+
+	conf := &reloadConf{}
+	require.NoError(t, parse(conf))
+	assert.Equal(t, 1, conf.Workers)
+
+	var diffs []ConfigDiff
+	OnConfigChange(func(diff ConfigDiff) {
+		if diff.Touches("reloadConf") {
+			diffs = append(diffs, diff)
+		}
+	})
+
+	// invalid updates are rejected as a whole
+	_, err := applyConfigUpdate("", map[string]any{"reloadConf": map[string]any{"addr": ":81", "workers": 0}, "other": 1}, nil)
+	require.ErrorContains(t, err, "workers must be positive")
+	assert.Equal(t, ":80", sf.GetString("reloadconf.addr"))
+	assert.Nil(t, sf.Get("other"))
+	assert.Zero(t, conf.reloaded)
+	assert.Empty(t, diffs)
+
+	diff, err := applyConfigUpdate("reloadConf", map[string]any{"addr": ":81", "workers": 2}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"reloadconf.addr", "reloadconf.workers"}, diff.Changed)
+	assert.Equal(t, 1, conf.reloaded)
+	assert.Equal(t, ":81", conf.Addr)
+	assert.Equal(t, 2, conf.Workers)
+	require.Len(t, diffs, 1)
+	assert.Equal(t, diff, diffs[0])
+
+	// updates not touching the key do not reload it
+	_, err = applyConfigUpdate("unrelated", "value", nil)
+	require.NoError(t, err)
+	assert.Equal(t, 1, conf.reloaded)
+}
+
+func TestApplyConfigUpdateConcurrentReplaceKey(t *testing.T) {
+	isolateGlobalFlags(t)
+
+	var wg sync.WaitGroup
+	for i := range 50 {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			_, err := applyConfigUpdate(fmt.Sprintf("update%d", i), i, nil)
+			assert.NoError(t, err)
+		}()
+		go func() {
+			defer wg.Done()
+			sf.ReplaceKey(fmt.Sprintf("replace%d", i), i)
+		}()
+	}
+	wg.Wait()
+
+	// neither writer overwrites the other
+	for i := range 50 {
+		assert.Equal(t, i, sf.Get(fmt.Sprintf("update%d", i)))
+		assert.Equal(t, i, sf.Get(fmt.Sprintf("replace%d", i)))
+	}
+}
+
+func TestApplyConfigUpdateSecretPaths(t *testing.T) {
+	isolateGlobalFlags(t)
+
+	var seen any
+	OnConfigChange(func(diff ConfigDiff) {
+		if diff.Touches("upstream") {
+			seen = AllSettingsRedacted()["upstream"]
+		}
+	})
+
+	// the new secret is already redacted when the hooks run
+	_, err := applyConfigUpdate("upstream", map[string]any{"auth": "s3cr3t"}, secretPaths{"upstream.auth": {}})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"auth": RedactedValue}, seen)
+
+	_, err = applyConfigUpdate("upstream", map[string]any{"auth": "plain"}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]any{"auth": "plain"}, seen)
+}
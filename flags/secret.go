@@ -0,0 +1,223 @@
+package flags
+
+import (
+	"context"
+	"maps"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/miebyte/goutils/consulutils"
+	"github.com/pkg/errors"
+)
+
+var (
+	// secretRefPattern matches secret references of the form ${scheme:ref}, e.g. ${env:DB_PASS}.
+	secretRefPattern = regexp.MustCompile(`\$\{([a-zA-Z][a-zA-Z0-9_-]*):([^}]+)\}`)
+
+	consulSecretTimeout = time.Second * 5
+
+	secretResolverMu sync.RWMutex
+	secretResolvers  = map[string]SecretResolver{
+		"env":    SecretResolverFunc(resolveEnvSecret),
+		"file":   SecretResolverFunc(resolveFileSecret),
+		"consul": SecretResolverFunc(resolveConsulSecret),
+	}
+)
+
+// SecretResolver resolves a secret reference in the config, ref is the part after the scheme in ${scheme:ref}.
+type SecretResolver interface {
+	Resolve(ref string) (string, error)
+}
+
+type SecretResolverFunc func(ref string) (string, error)
+
+func (f SecretResolverFunc) Resolve(ref string) (string, error) {
+	return f(ref)
+}
+
+// RegisterSecretResolver registers the SecretResolver of scheme, overriding the built-in one of the same name.
+//
+// Built-in schemes:
+//   - env: reads an environment variable, e.g. ${env:DB_PASS}
+//   - file: reads a file and trims the trailing newline, e.g. ${file:/run/secrets/db}
+//   - consul: reads a consul KV, e.g. ${consul:secrets/db/password}
+func RegisterSecretResolver(scheme string, r SecretResolver) {
+	secretResolverMu.Lock()
+	secretResolvers[scheme] = r
+	secretResolverMu.Unlock()
+}
+
+func getSecretResolver(scheme string) (SecretResolver, bool) {
+	secretResolverMu.RLock()
+	defer secretResolverMu.RUnlock()
+
+	r, ok := secretResolvers[scheme]
+	return r, ok
+}
+
+func resolveEnvSecret(ref string) (string, error) {
+	val, ok := os.LookupEnv(ref)
+	if !ok {
+		return "", errors.Errorf("env %s not set", ref)
+	}
+	return val, nil
+}
+
+func resolveFileSecret(ref string) (string, error) {
+	b, err := os.ReadFile(ref)
+	if err != nil {
+		return "", errors.Wrap(err, "readFile")
+	}
+	return strings.TrimRight(string(b), "\r\n"), nil
+}
+
+func resolveConsulSecret(ref string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), consulSecretTimeout)
+	defer cancel()
+
+	entry, err := consulutils.GetKV[string](ctx, consulutils.GetConsulClient(), ref)
+	if err != nil {
+		return "", err
+	}
+	return entry.Value, nil
+}
+
+// secretPaths records the lower-cased key paths whose values were resolved from
+// secret references. Slice elements are addressed by index, e.g. hosts.1.
+type secretPaths map[string]struct{}
+
+func (p secretPaths) add(path string) {
+	p[strings.ToLower(path)] = struct{}{}
+}
+
+func (p secretPaths) has(path string) bool {
+	_, ok := p[strings.ToLower(path)]
+	return ok
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// resolveSecrets returns a copy of cfg with secret references replaced by their
+// values, along with the key paths of the resolved values.
+//
+// It fails if any reference cannot be resolved. The error only contains the key and
+// the reference, never the secret itself.
+func resolveSecrets(cfg map[string]any) (map[string]any, secretPaths, error) {
+	paths := make(secretPaths)
+	resolved, err := resolveSecretValue("", cfg, paths)
+	if err != nil {
+		return nil, nil, err
+	}
+	return resolved.(map[string]any), paths, nil
+}
+
+func resolveSecretValue(path string, val any, paths secretPaths) (any, error) {
+	switch v := val.(type) {
+	case string:
+		return resolveSecretString(path, v, paths)
+	case map[string]any:
+		if v == nil {
+			return v, nil
+		}
+		out := make(map[string]any, len(v))
+		for key, item := range v {
+			resolved, err := resolveSecretValue(joinPath(path, key), item, paths)
+			if err != nil {
+				return nil, err
+			}
+			out[key] = resolved
+		}
+		return out, nil
+	case []any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			resolved, err := resolveSecretValue(joinPath(path, strconv.Itoa(i)), item, paths)
+			if err != nil {
+				return nil, err
+			}
+			out[i] = resolved
+		}
+		return out, nil
+	default:
+		return v, nil
+	}
+}
+
+func resolveSecretString(path, s string, paths secretPaths) (string, error) {
+	if !strings.Contains(s, "${") {
+		return s, nil
+	}
+
+	var firstErr error
+	out := secretRefPattern.ReplaceAllStringFunc(s, func(ref string) string {
+		m := secretRefPattern.FindStringSubmatch(ref)
+		r, ok := getSecretResolver(m[1])
+		if !ok {
+			if firstErr == nil {
+				firstErr = errors.Errorf("resolve %s of key(%s): unknown secret scheme %q", ref, path, m[1])
+			}
+			return ref
+		}
+
+		val, err := r.Resolve(m[2])
+		if err != nil {
+			if firstErr == nil {
+				firstErr = errors.Wrapf(err, "resolve %s of key(%s)", ref, path)
+			}
+			return ref
+		}
+		return val
+	})
+	if firstErr != nil {
+		return "", firstErr
+	}
+
+	if out != s {
+		paths.add(path)
+	}
+	return out, nil
+}
+
+// setSecretPaths records the secret key paths of a successful load. An empty key
+// means the whole config was loaded and replaces every recorded path, otherwise
+// only the paths under key are replaced.
+func (sf *SuperFlags) setSecretPaths(key string, paths secretPaths) {
+	sf.mu.Lock()
+	defer sf.mu.Unlock()
+	sf.setSecretPathsLocked(key, paths)
+}
+
+// setSecretPathsLocked is setSecretPaths with sf.mu held.
+func (sf *SuperFlags) setSecretPathsLocked(key string, paths secretPaths) {
+	if paths == nil {
+		paths = make(secretPaths)
+	}
+
+	key = strings.ToLower(key)
+	if key == "" || sf.secretPaths == nil {
+		sf.secretPaths = paths
+		return
+	}
+
+	for path := range sf.secretPaths {
+		if path == key || strings.HasPrefix(path, key+".") {
+			delete(sf.secretPaths, path)
+		}
+	}
+	maps.Copy(sf.secretPaths, paths)
+}
+
+func (sf *SuperFlags) getSecretPaths() secretPaths {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return maps.Clone(sf.secretPaths)
+}
@@ -0,0 +1,107 @@
+package flags
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/miebyte/goutils/flags/provider"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResolveSecrets(t *testing.T) {
+	t.Setenv("TEST_DB_PASS", "db-secret")
+	secretFile := filepath.Join(t.TempDir(), "smtp")
+	require.NoError(t, os.WriteFile(secretFile, []byte("smtp-secret\n"), 0o600))
+
+	cfg, paths, err := resolveSecrets(map[string]any{
+		"mysql": map[string]any{
+			"user": "root",
+			"pass": "${env:TEST_DB_PASS}",
+			"dsn":  "root:${env:TEST_DB_PASS}@tcp(db:3306)/app",
+		},
+		"email":  map[string]any{"auth": "${file:" + secretFile + "}"},
+		"hosts":  []any{"a", "${env:TEST_DB_PASS}"},
+		"nested": "${notref}",
+	})
+	require.NoError(t, err)
+
+	mysql := cfg["mysql"].(map[string]any)
+	assert.Equal(t, "db-secret", mysql["pass"])
+	assert.Equal(t, "root:db-secret@tcp(db:3306)/app", mysql["dsn"])
+	assert.Equal(t, "smtp-secret", cfg["email"].(map[string]any)["auth"])
+	assert.Equal(t, []any{"a", "db-secret"}, cfg["hosts"])
+	assert.Equal(t, "${notref}", cfg["nested"])
+	assert.Equal(t, secretPaths{"mysql.pass": {}, "mysql.dsn": {}, "email.auth": {}, "hosts.1": {}}, paths)
+
+	redacted := redactMap("", cfg, paths)
+	assert.Equal(t, "root", redacted["mysql"].(map[string]any)["user"])
+	assert.Equal(t, RedactedValue, redacted["mysql"].(map[string]any)["dsn"])
+	assert.Equal(t, RedactedValue, redacted["email"].(map[string]any)["auth"])
+	assert.Equal(t, []any{"a", RedactedValue}, redacted["hosts"])
+}
+
+func TestRedactSecretPaths(t *testing.T) {
+	t.Setenv("TEST_SHARED_SECRET", "shared")
+
+	s := New()
+	s.SetConfigProvider(staticProvider{"Auth": "${env:TEST_SHARED_SECRET}", "name": "shared"})
+	require.NoError(t, s.ReadConfig())
+
+	// plain values equal to a secret are kept
+	redacted := s.Redact(s.AllSettings())
+	assert.Equal(t, RedactedValue, redacted["auth"])
+	assert.Equal(t, "shared", redacted["name"])
+
+	// paths are reset on every successful load
+	s.SetConfigProvider(staticProvider{"auth": "plain"})
+	require.NoError(t, s.ReadConfig())
+	assert.Equal(t, "plain", s.Redact(s.AllSettings())["auth"])
+
+	s.SetConfigProvider(staticProvider{"auth": "${env:TEST_NOT_SET_SECRET}"})
+	require.Error(t, s.ReadConfig())
+	assert.Equal(t, "plain", s.Redact(s.AllSettings())["auth"])
+
+	// a keyed update only replaces the paths under the key
+	s.setSecretPaths("", secretPaths{"db.pass": {}, "auth": {}})
+	s.setSecretPaths("db", secretPaths{"db.user": {}})
+	assert.Equal(t, secretPaths{"db.user": {}, "auth": {}}, s.getSecretPaths())
+}
+
+func TestResolveSecretsError(t *testing.T) {
+	_, _, err := resolveSecrets(map[string]any{"pass": "${env:TEST_NOT_SET_SECRET}"})
+	assert.ErrorContains(t, err, "key(pass)")
+
+	_, _, err = resolveSecrets(map[string]any{"pass": "${vault:db}"})
+	assert.ErrorContains(t, err, "unknown secret scheme")
+}
+
+func TestRegisterSecretResolver(t *testing.T) {
+	RegisterSecretResolver("test", SecretResolverFunc(func(ref string) (string, error) {
+		if ref == "missing" {
+			return "", errors.New("not found")
+		}
+		return "resolved-" + ref, nil
+	}))
+
+	s := New()
+	s.SetConfigProvider(staticProvider{"token": "${test:api}"})
+	require.NoError(t, s.ReadConfig())
+	assert.Equal(t, "resolved-api", s.GetString("token"))
+
+	s.SetConfigProvider(staticProvider{"token": "${test:missing}"})
+	assert.Error(t, s.ReadConfig())
+	assert.Equal(t, "resolved-api", s.GetString("token"))
+}
+
+type staticProvider map[string]any
+
+func (p staticProvider) ReadConfig() (map[string]any, error) {
+	return p, nil
+}
+
+func (p staticProvider) WatchConfig() <-chan provider.Event {
+	return nil
+}
@@ -38,6 +38,7 @@ func Struct[T any](key string, defaultVal T, usage string) StructParser[T] {
 	}
 
 	sf.SetDefault(key, defaultVal)
+	registerSchema(key, reflect.TypeOf(defaultVal))
 	return func(out T) error {
 		if reflect.TypeOf(out).Kind() != reflect.Pointer {
 			return errors.New("out must be a pointer")
@@ -74,6 +75,10 @@ func SetStructParseTagName(t string) {
 }
 
 func unmarshalKey(key string, out any) error {
+	return sf.unmarshalKey(key, out)
+}
+
+func (sf *SuperFlags) unmarshalKey(key string, out any) error {
 	val := sf.Get(key)
 	if val == nil {
 		return nil
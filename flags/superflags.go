@@ -4,6 +4,7 @@ import (
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"maps"
 	"path/filepath"
 	"slices"
 	"strings"
@@ -23,7 +24,12 @@ type ConfigProvider interface {
 }
 
 // SuperFlags is a lightweight configuration and flag manager.
-// It searches in pflags, then in loaded config, then in defaults.
+// Lookups use the following precedence, highest first:
+//
+//  1. pflags explicitly set on the command line
+//  2. environment variables (see AutomaticEnv and BindEnv)
+//  3. loaded config, local file or remote
+//  4. defaults, including the default values of pflags
 type SuperFlags struct {
 	// A set of paths to look for the config file in
 	configPaths    []string
@@ -42,6 +48,14 @@ type SuperFlags struct {
 	defaults map[string]any
 	pflags   map[string]FlagValue
 
+	// environment variable layer
+	envPrefix    string
+	automaticEnv bool
+	envBindings  map[string][]string
+
+	// key paths resolved from secret references by the last load
+	secretPaths secretPaths
+
 	// integrated components
 	configProvider ConfigProvider
 
@@ -77,6 +91,13 @@ func (sf *SuperFlags) SetConfigName(in string) {
 	}
 }
 
+// ConfigType returns the config type set by SetConfigType.
+func (sf *SuperFlags) ConfigType() string {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+	return sf.configType
+}
+
 func (sf *SuperFlags) SetConfigType(in string) {
 	if in != "" {
 		sf.mu.Lock()
@@ -166,6 +187,42 @@ func (sf *SuperFlags) ReplaceKey(key string, value any) {
 	sf.mu.Unlock()
 }
 
+// candidateConfig returns a copy of the config with key replaced by value,
+// or the whole config replaced when key is empty. It reports false for a nil value.
+// It must be called with sf.mu held.
+func (sf *SuperFlags) candidateConfig(key string, value any) (map[string]any, bool) {
+	if key == "" {
+		cfg, _ := value.(map[string]any)
+		if cfg == nil {
+			return nil, false
+		}
+		return copyAndInsensitiviseMap(cfg), true
+	}
+	if value == nil {
+		return nil, false
+	}
+
+	cfg := maps.Clone(sf.config)
+	cfg[strings.ToLower(key)] = toCaseInsensitiveValue(value)
+	return cfg, true
+}
+
+// withConfig returns a read-only view of sf using cfg as the loaded config,
+// keeping pflags, env and defaults, so values can be decoded before cfg goes live.
+// It must be called with sf.mu held.
+func (sf *SuperFlags) withConfig(cfg map[string]any) *SuperFlags {
+	return &SuperFlags{
+		fs:             sf.fs,
+		config:         cfg,
+		defaults:       maps.Clone(sf.defaults),
+		pflags:         maps.Clone(sf.pflags),
+		envPrefix:      sf.envPrefix,
+		automaticEnv:   sf.automaticEnv,
+		envBindings:    maps.Clone(sf.envBindings),
+		configProvider: sf.configProvider,
+	}
+}
+
 func (sf *SuperFlags) Get(key string) any {
 	lcaseKey := strings.ToLower(key)
 	sf.mu.RLock()
@@ -178,17 +235,90 @@ func (sf *SuperFlags) Get(key string) any {
 		}
 	}
 
-	// search sf.Config next
-	if val, ok := sf.config[lcaseKey]; ok {
+	// search env next
+	if val, ok := sf.lookupEnv(lcaseKey); ok {
 		return val
 	}
 
-	// search sf.Defaults next
-	if val, ok := sf.defaults[lcaseKey]; ok {
-		return val
+	// search sf.Config next, then sf.Defaults
+	val, ok := searchPath(sf.config, lcaseKey)
+	if !ok {
+		val, ok = searchPath(sf.defaults, lcaseKey)
+	}
+	if !ok {
+		return nil
 	}
 
-	return nil
+	// nested keys of map values can be overridden by env
+	if m, isMap := val.(map[string]any); isMap {
+		return sf.overlayEnv(lcaseKey, m)
+	}
+	return val
+}
+
+// AllSettings returns the effective value of every known key,
+// with the same precedence as Get: changed pflags, then env, then config, then defaults.
+// Unchanged pflags without env, config or default fall back to the flag's default value.
+func (sf *SuperFlags) AllSettings() map[string]any {
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+
+	settings := make(map[string]any, len(sf.pflags)+len(sf.config)+len(sf.defaults))
+	for k, v := range sf.defaults {
+		settings[k] = v
+	}
+	for k, v := range sf.config {
+		settings[k] = v
+	}
+	for k, v := range settings {
+		if val, ok := sf.lookupEnv(k); ok {
+			settings[k] = val
+		} else if m, isMap := v.(map[string]any); isMap {
+			settings[k] = sf.overlayEnv(k, m)
+		}
+	}
+	for k, flag := range sf.pflags {
+		_, exists := settings[k]
+		switch {
+		case flag.HasChanged():
+			settings[k] = flag.ValueString()
+		case !exists:
+			if val, ok := sf.lookupEnv(k); ok {
+				settings[k] = val
+			} else {
+				settings[k] = flag.ValueString()
+			}
+		}
+	}
+	return settings
+}
+
+// Source reports which layer supplies the effective value of key:
+// "flag", "env:<NAME>", the config source name when the provider tracks provenance
+// (see LayeredProvider), "config", or "default". It returns "" for unknown keys.
+func (sf *SuperFlags) Source(key string) string {
+	lcaseKey := strings.ToLower(key)
+	sf.mu.RLock()
+	defer sf.mu.RUnlock()
+
+	if flag, ok := sf.pflags[lcaseKey]; ok && flag.HasChanged() {
+		return "flag"
+	}
+	if name, _, ok := sf.envSource(lcaseKey); ok {
+		return "env:" + name
+	}
+	if _, ok := searchPath(sf.config, lcaseKey); ok {
+		if sourcer, ok := sf.configProvider.(ConfigSourcer); ok {
+			if name, ok := sourcer.Source(lcaseKey); ok {
+				return name
+			}
+		}
+		return "config"
+	}
+	if _, ok := searchPath(sf.defaults, lcaseKey); ok {
+		return "default"
+	}
+	return ""
 }
 
 func (sf *SuperFlags) Set(key string, value string) {
@@ -254,7 +384,8 @@ func (sf *SuperFlags) GetStringSlice(key string) []string {
 }
 
 // FindConfigFile searches known config paths for a config file named
-// {configName}.{configType}. If SetConfigFile was called and the file exists,
+// {configName}.{configType}, falling back to the other supported extensions
+// (json, yaml, yml, toml, env). If SetConfigFile was called and the file exists,
 // it returns that path first. Returns empty string if none found.
 func (sf *SuperFlags) FindConfigFile() string {
 	sf.findConfigOnce.Do(func() {
@@ -279,20 +410,32 @@ func (sf *SuperFlags) FindConfigFile() string {
 			}
 		}
 
-		if cn == "" || ct == "" {
+		if cn == "" {
 			return
 		}
-		filename := fmt.Sprintf("%s.%s", cn, ct)
+
+		// the configured type is tried first, then every other supported extension
+		exts := make([]string, 0, len(provider.SupportedExts)+1)
+		if ct != "" {
+			exts = append(exts, ct)
+		}
+		for _, ext := range provider.SupportedExts {
+			if !slices.Contains(exts, ext) {
+				exts = append(exts, ext)
+			}
+		}
 
 		for _, dir := range paths {
-			candidate := filepath.Join(dir, filename)
-			if _, err := fsys.Stat(candidate); err == nil {
-				sf.mu.Lock()
-				sf.findConfigPath = candidate
-				// set discovered path into config map (lower-cased key)
-				sf.config["configfile"] = candidate
-				sf.mu.Unlock()
-				return
+			for _, ext := range exts {
+				candidate := filepath.Join(dir, fmt.Sprintf("%s.%s", cn, ext))
+				if _, err := fsys.Stat(candidate); err == nil {
+					sf.mu.Lock()
+					sf.findConfigPath = candidate
+					// set discovered path into config map (lower-cased key)
+					sf.config["configfile"] = candidate
+					sf.mu.Unlock()
+					return
+				}
 			}
 		}
 	})
@@ -321,7 +464,8 @@ func (sf *SuperFlags) SetConfigProvider(r ConfigProvider) {
 	sf.mu.Unlock()
 }
 
-// ReadConfig reads configuration via the integrated reader and replaces in-memory config.
+// ReadConfig reads configuration via the integrated reader, resolves secret references
+// such as ${env:DB_PASS} and replaces in-memory config.
 func (sf *SuperFlags) ReadConfig() error {
 	sf.mu.RLock()
 	r := sf.configProvider
@@ -333,9 +477,17 @@ func (sf *SuperFlags) ReadConfig() error {
 	if err != nil {
 		return err
 	}
-	if cfg != nil {
-		sf.ReplaceConfig(cfg)
+	if cfg == nil {
+		return nil
+	}
+
+	cfg, paths, err := resolveSecrets(cfg)
+	if err != nil {
+		return err
 	}
+	// record the paths first, so the new secrets are never visible unredacted
+	sf.setSecretPaths("", paths)
+	sf.ReplaceConfig(cfg)
 	return nil
 }
 
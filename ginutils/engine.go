@@ -44,6 +44,7 @@ func Default(opts ...gin.OptionFunc) *gin.Engine {
 	engine := gin.New()
 	engine.Use(
 		LoggerMiddleware(),
+		RouteMetrics(),
 		gin.CustomRecovery(customRecoveryFn),
 	)
 	return engine.With(opts...)
@@ -20,6 +20,7 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/miebyte/goutils/logging"
 	"github.com/miebyte/goutils/logging/level"
+	"github.com/miebyte/goutils/prometheusutils"
 )
 
 const maxBodyLen = 1024
@@ -34,6 +35,14 @@ func init() {
 	Logger.Enable(level.LevelDebug)
 }
 
+// RouteMetrics 将 gin 匹配到的路由模板回填给接口监控, 避免以原始路径作为监控标签
+func RouteMetrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		prometheusutils.SetRoute(c.Request.Context(), c.FullPath())
+		c.Next()
+	}
+}
+
 // LoggingRequest 打印请求体
 func LoggingRequest(header bool) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -0,0 +1,341 @@
+// File:		client.go
+// Created by:	Hoven
+// Created on:	2026-10-17
+//
+// This file is part of the Example Project.
+//
+// (c) 2024 Example Corp. All rights reserved.
+
+// Package httpclient 提供基于服务发现的 http 客户端
+//
+// 请求地址中的 host 为服务名, 每次请求(包括重试)通过 discover 的负载均衡器选择节点,
+// 并集成熔断, 幂等请求重试, 超时, 日志字段透传与客户端监控。
+package httpclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"math/rand/v2"
+	"net/http"
+	"time"
+
+	"github.com/miebyte/goutils/breaker"
+	"github.com/miebyte/goutils/discover"
+	"github.com/miebyte/goutils/logging"
+	"github.com/miebyte/goutils/prometheusutils"
+	"github.com/pkg/errors"
+)
+
+const (
+	breakerPrefix = "httpclient:"
+	maxDrainBytes = 4096
+)
+
+var (
+	defaultTimeout         = time.Second * 5
+	defaultMaxRetries      = 2
+	defaultRetryBackoff    = time.Millisecond * 100
+	defaultMaxRetryBackoff = time.Second
+
+	// defaultPropagateFields 默认透传的日志字段及对应的请求头
+	defaultPropagateFields = map[string]string{
+		"RequestID": "X-Request-Id",
+	}
+)
+
+type hashKeyCtxKey struct{}
+
+// WithHashKey 设置本次请求用于一致性哈希的 key, 仅在服务使用 discover.ConsistentHash 策略时生效
+func WithHashKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, hashKeyCtxKey{}, key)
+}
+
+func hashKey(ctx context.Context) string {
+	key, _ := ctx.Value(hashKeyCtxKey{}).(string)
+	return key
+}
+
+// Client 调用单个服务的 http 客户端, 可并发使用
+type Client struct {
+	service string
+	tag     string
+	scheme  string
+
+	client          *http.Client
+	timeout         time.Duration
+	maxRetries      int
+	retryBackoff    time.Duration
+	maxRetryBackoff time.Duration
+	breaker         breaker.Breaker
+	headers         http.Header
+	propagate       map[string]string
+}
+
+type Option func(*Client)
+
+// WithTag 通过带 tag 的服务节点发起请求
+func WithTag(tag string) Option {
+	return func(c *Client) {
+		c.tag = tag
+	}
+}
+
+// WithScheme 设置请求使用的协议, 默认 http
+func WithScheme(scheme string) Option {
+	return func(c *Client) {
+		c.scheme = scheme
+	}
+}
+
+// WithHTTPClient 使用自定义的 http.Client(如自定义 Transport), 其 Timeout 会被 WithTimeout 覆盖
+func WithHTTPClient(client *http.Client) Option {
+	return func(c *Client) {
+		if client != nil {
+			c.client = client
+		}
+	}
+}
+
+// WithTimeout 设置单次请求(包括读取响应体)的超时, 默认 5s, 整体超时由 ctx 控制
+func WithTimeout(timeout time.Duration) Option {
+	return func(c *Client) {
+		c.timeout = timeout
+	}
+}
+
+// WithRetry 设置幂等请求失败后的最大重试次数与初始退避间隔, 默认重试 2 次, 初始间隔 100ms
+func WithRetry(maxRetries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries = maxRetries
+		if backoff > 0 {
+			c.retryBackoff = backoff
+			c.maxRetryBackoff = max(c.maxRetryBackoff, backoff)
+		}
+	}
+}
+
+// WithBreaker 使用指定的熔断器, 默认使用 breaker.GetBreaker("httpclient:<service>")
+func WithBreaker(b breaker.Breaker) Option {
+	return func(c *Client) {
+		c.breaker = b
+	}
+}
+
+// WithoutBreaker 关闭熔断
+func WithoutBreaker() Option {
+	return WithBreaker(breaker.NopBreaker())
+}
+
+// WithHeader 为每个请求设置请求头
+func WithHeader(key, value string) Option {
+	return func(c *Client) {
+		c.headers.Set(key, value)
+	}
+}
+
+// WithPropagateField 将 ctx 中的日志字段 field 通过请求头 header 透传给下游, 默认透传 RequestID 到 X-Request-Id
+func WithPropagateField(field, header string) Option {
+	return func(c *Client) {
+		c.propagate[field] = header
+	}
+}
+
+// New 创建调用 service 的客户端, service 也可以是 ip:port 形式的地址
+func New(service string, opts ...Option) *Client {
+	c := &Client{
+		service:         service,
+		scheme:          "http",
+		client:          http.DefaultClient,
+		timeout:         defaultTimeout,
+		maxRetries:      defaultMaxRetries,
+		retryBackoff:    defaultRetryBackoff,
+		maxRetryBackoff: defaultMaxRetryBackoff,
+		headers:         make(http.Header),
+		propagate:       maps.Clone(defaultPropagateFields),
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+
+	if c.breaker == nil {
+		c.breaker = breaker.GetBreaker(breakerPrefix + service)
+	}
+
+	hc := *c.client
+	hc.Timeout = c.timeout
+	c.client = &hc
+	return c
+}
+
+// Service 返回客户端调用的服务名
+func (c *Client) Service() string {
+	return c.service
+}
+
+// NewRequest 创建请求, path 为包含查询参数的路径, 如 "/api/v1/users?id=1"
+func (c *Client) NewRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	return http.NewRequestWithContext(ctx, method, fmt.Sprintf("%s://%s%s", c.scheme, c.service, path), body)
+}
+
+func (c *Client) Get(ctx context.Context, path string) (*http.Response, error) {
+	req, err := c.NewRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(req)
+}
+
+func (c *Client) Post(ctx context.Context, path, contentType string, body io.Reader) (*http.Response, error) {
+	req, err := c.NewRequest(ctx, http.MethodPost, path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", contentType)
+	return c.Do(req)
+}
+
+// Do 发送请求, 请求的 host 会被替换为服务节点的地址
+//
+// 幂等方法(GET/HEAD/OPTIONS/TRACE/PUT/DELETE)或带 Idempotency-Key 请求头的请求,
+// 在连接失败或返回 502/503/504 时按指数退避重试, 请求体需支持 GetBody。
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	c.prepare(req)
+
+	retryable := c.maxRetries > 0 && isIdempotent(req) &&
+		(req.Body == nil || req.Body == http.NoBody || req.GetBody != nil)
+	backoff := c.retryBackoff
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.doOnce(req)
+		if !retryable || attempt >= c.maxRetries || !shouldRetry(resp, err) || ctx.Err() != nil {
+			return resp, err
+		}
+
+		if resp != nil {
+			_, _ = io.CopyN(io.Discard, resp.Body, maxDrainBytes)
+			resp.Body.Close()
+		}
+
+		prometheusutils.SendHTTPClientRetryCounter(c.service, req.Method)
+		delay := backoff/2 + rand.N(backoff/2+1)
+		logging.Warnc(ctx, "httpclient: %s %s%s failed, retry in %v. Status=%d Err=%v",
+			req.Method, c.service, req.URL.Path, delay, statusCode(resp), err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, errors.Wrapf(ctx.Err(), "httpclient: %s %s%s", req.Method, c.service, req.URL.Path)
+		case <-timer.C:
+		}
+		backoff = min(backoff*2, c.maxRetryBackoff)
+
+		if req.GetBody != nil {
+			body, err := req.GetBody()
+			if err != nil {
+				return nil, errors.Wrap(err, "httpclient: get body for retry")
+			}
+			req.Body = body
+		}
+	}
+}
+
+// prepare 设置默认请求头, 并将 ctx 中的日志字段透传到请求头
+func (c *Client) prepare(req *http.Request) {
+	for key, values := range c.headers {
+		if req.Header.Get(key) == "" {
+			req.Header[key] = values
+		}
+	}
+
+	fields := logging.GetContextFields(req.Context())
+	for field, header := range c.propagate {
+		if req.Header.Get(header) != "" {
+			continue
+		}
+
+		value, ok := fields[field]
+		if !ok {
+			// 兼容 gin.Context 中通过 c.Set 设置的字段
+			value = req.Context().Value(field)
+		}
+		if value != nil {
+			req.Header.Set(header, fmt.Sprint(value))
+		}
+	}
+}
+
+func (c *Client) doOnce(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+
+	promise, err := c.breaker.AllowCtx(ctx)
+	if err != nil {
+		return nil, errors.Wrapf(err, "httpclient: service(%s)", c.service)
+	}
+
+	addr := c.service
+	var sel *discover.Selection
+	if !discover.IsAddress(c.service) {
+		sel, err = discover.PickWithTag(c.service, c.tag, hashKey(ctx))
+		if err != nil {
+			promise.Reject(err.Error())
+			return nil, errors.Wrap(err, "httpclient")
+		}
+		addr = sel.Address
+	}
+
+	r := req.Clone(ctx)
+	r.URL.Scheme = c.scheme
+	r.URL.Host = addr
+	r.Host = ""
+
+	start := time.Now()
+	resp, err := c.client.Do(r)
+	prometheusutils.SendHTTPClientHistogram(c.service, req.Method, statusCode(resp), float64(time.Since(start).Milliseconds()))
+
+	failure := err
+	if failure == nil && resp.StatusCode >= http.StatusInternalServerError {
+		failure = errors.Errorf("status code %d", resp.StatusCode)
+	}
+	// 调用方主动取消不计入下游的失败
+	if failure != nil && ctx.Err() == nil {
+		promise.Reject(failure.Error())
+	} else {
+		promise.Accept()
+		failure = nil
+	}
+	if sel != nil {
+		sel.Done(failure)
+	}
+	return resp, err
+}
+
+func statusCode(resp *http.Response) int {
+	if resp == nil {
+		return 0
+	}
+	return resp.StatusCode
+}
+
+func isIdempotent(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace, http.MethodPut, http.MethodDelete:
+		return true
+	}
+	return req.Header.Get("Idempotency-Key") != ""
+}
+
+func shouldRetry(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, breaker.ErrServiceUnavailable) && !errors.Is(err, discover.ErrNoEndpoint)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	}
+	return false
+}
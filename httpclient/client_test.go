@@ -0,0 +1,278 @@
+package httpclient
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/miebyte/goutils/breaker"
+	"github.com/miebyte/goutils/discover"
+	"github.com/miebyte/goutils/ginutils"
+	"github.com/miebyte/goutils/logging"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestServer(t *testing.T, handler http.HandlerFunc) *Client {
+	t.Helper()
+
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	return New(strings.TrimPrefix(srv.URL, "http://"), WithRetry(2, time.Millisecond), WithoutBreaker())
+}
+
+func TestClientRetryIdempotent(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		_, _ = io.WriteString(w, "ok")
+	})
+
+	resp, err := c.Get(context.Background(), "/ping")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	body, _ := io.ReadAll(resp.Body)
+	assert.Equal(t, "ok", string(body))
+	assert.EqualValues(t, 3, calls.Load())
+}
+
+func TestClientRetryWithBody(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		assert.Equal(t, "payload", string(body))
+		if calls.Add(1) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	})
+
+	req, err := c.NewRequest(context.Background(), http.MethodPut, "/item", strings.NewReader("payload"))
+	require.NoError(t, err)
+	resp, err := c.Do(req)
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.EqualValues(t, 2, calls.Load())
+}
+
+func TestClientNoRetryNonIdempotent(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	})
+
+	resp, err := c.Post(context.Background(), "/item", "text/plain", strings.NewReader("x"))
+	require.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+	assert.EqualValues(t, 1, calls.Load())
+}
+
+func TestClientPropagateHeaders(t *testing.T) {
+	c := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "req-1", r.Header.Get("X-Request-Id"))
+		assert.Equal(t, "alice", r.Header.Get("X-User"))
+		assert.Equal(t, "v1", r.Header.Get("X-Static"))
+	})
+	c = New(c.service, WithoutBreaker(), WithPropagateField("User", "X-User"), WithHeader("X-Static", "v1"))
+
+	ctx := logging.With(context.Background(), "RequestID", "req-1")
+	ctx = logging.With(ctx, "User", "alice")
+	resp, err := c.Get(ctx, "/")
+	require.NoError(t, err)
+	resp.Body.Close()
+}
+
+func TestClientBreaker(t *testing.T) {
+	var calls atomic.Int32
+	c := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	c = New(c.service, WithRetry(0, 0), WithBreaker(breaker.NewBreaker()))
+
+	var rejected bool
+	for range 200 {
+		resp, err := c.Get(context.Background(), "/")
+		if err != nil {
+			assert.ErrorIs(t, err, breaker.ErrServiceUnavailable)
+			rejected = true
+			continue
+		}
+		resp.Body.Close()
+	}
+	assert.True(t, rejected)
+	assert.Less(t, calls.Load(), int32(200))
+}
+
+type user struct {
+	Name string `json:"name"`
+}
+
+func TestDoJSON(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := gin.New()
+	engine.GET("/user", func(c *gin.Context) {
+		ginutils.ReturnSuccess(c, user{Name: "alice"})
+	})
+	engine.POST("/user", func(c *gin.Context) {
+		ginutils.ReturnError(c, &ResponseError{ErrCode: 1001, Message: "exists"})
+	})
+	engine.DELETE("/user", func(c *gin.Context) {
+		ginutils.ReturnErrorWithCode(c, http.StatusForbidden, "forbidden")
+	})
+
+	srv := httptest.NewServer(engine)
+	defer srv.Close()
+	c := New(strings.TrimPrefix(srv.URL, "http://"), WithoutBreaker())
+	ctx := context.Background()
+
+	u, err := GetJSON[user](ctx, c, "/user")
+	require.NoError(t, err)
+	assert.Equal(t, "alice", u.Name)
+
+	_, err = PostJSON[user](ctx, c, "/user", user{Name: "alice"})
+	var respErr *ResponseError
+	require.ErrorAs(t, err, &respErr)
+	assert.Equal(t, 1001, respErr.Code())
+	assert.Equal(t, http.StatusOK, respErr.StatusCode)
+
+	_, err = DoJSON[user](ctx, c, http.MethodDelete, "/user", nil)
+	require.ErrorAs(t, err, &respErr)
+	assert.Equal(t, http.StatusForbidden, respErr.StatusCode)
+	assert.Equal(t, "forbidden", respErr.Message)
+}
+
+func TestDoJSONEmptyBody(t *testing.T) {
+	c := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	ctx := context.Background()
+
+	u, err := DoJSON[user](ctx, c, http.MethodDelete, "/user", nil)
+	require.NoError(t, err)
+	assert.Zero(t, u)
+
+	u, err = GetJSON[user](ctx, c, "/user")
+	require.NoError(t, err)
+	assert.Zero(t, u)
+}
+
+// testFinder 通过 Watch 返回固定的节点列表, 并记录订阅的 tag
+type testFinder struct {
+	*discover.DirectFinder
+	endpoints []discover.Endpoint
+	tags      chan string
+}
+
+func (f *testFinder) Watch(ctx context.Context, service, tag string) <-chan []discover.Endpoint {
+	select {
+	case f.tags <- tag:
+	default:
+	}
+
+	ch := make(chan []discover.Endpoint, 1)
+	ch <- f.endpoints
+	go func() {
+		<-ctx.Done()
+		close(ch)
+	}()
+	return ch
+}
+
+func setTestFinder(t *testing.T, service string, addrs []string, opts ...discover.BalancerOption) *testFinder {
+	t.Helper()
+
+	f := &testFinder{DirectFinder: discover.NewDirectFinder(), tags: make(chan string, 8)}
+	for _, addr := range addrs {
+		f.endpoints = append(f.endpoints, discover.Endpoint{Address: addr, Weight: 1})
+	}
+
+	prev := discover.GetServiceFinder()
+	discover.SetFinder(f)
+	discover.SetBalancer(service, opts...)
+	t.Cleanup(func() { discover.SetFinder(prev) })
+	return f
+}
+
+func newCountingServer(t *testing.T, status int, hits *atomic.Int32) string {
+	t.Helper()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits.Add(1)
+		w.WriteHeader(status)
+	}))
+	t.Cleanup(srv.Close)
+	return strings.TrimPrefix(srv.URL, "http://")
+}
+
+func TestClientBalancerRetry(t *testing.T) {
+	var badHits, goodHits atomic.Int32
+	good := newCountingServer(t, http.StatusOK, &goodHits)
+	bad := newCountingServer(t, http.StatusServiceUnavailable, &badHits)
+
+	// 轮询从第二个节点开始, 第一次请求会先落到 bad 上
+	f := setTestFinder(t, "balancer-retry", []string{good, bad},
+		discover.WithStrategy(discover.RoundRobin),
+		discover.WithOutlierEjection(1, time.Hour, 50),
+	)
+	c := New("balancer-retry", WithTag("v2"), WithRetry(2, time.Millisecond), WithoutBreaker())
+
+	resp, err := c.Get(context.Background(), "/")
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.EqualValues(t, 1, badHits.Load())
+	assert.EqualValues(t, 1, goodHits.Load())
+	assert.Equal(t, "v2", <-f.tags)
+
+	// 失败结果经 Done 反馈后 bad 被摘除
+	for range 5 {
+		resp, err := c.Get(context.Background(), "/")
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+	assert.EqualValues(t, 1, badHits.Load())
+	assert.EqualValues(t, 6, goodHits.Load())
+}
+
+func TestClientBalancerHashKey(t *testing.T) {
+	var hitsA, hitsB atomic.Int32
+	a := newCountingServer(t, http.StatusOK, &hitsA)
+	b := newCountingServer(t, http.StatusOK, &hitsB)
+
+	setTestFinder(t, "balancer-hash", []string{a, b}, discover.WithStrategy(discover.ConsistentHash))
+	c := New("balancer-hash", WithoutBreaker())
+
+	ctx := WithHashKey(context.Background(), "user-1")
+	for range 10 {
+		resp, err := c.Get(ctx, "/")
+		require.NoError(t, err)
+		resp.Body.Close()
+	}
+	assert.ElementsMatch(t, []int32{0, 10}, []int32{hitsA.Load(), hitsB.Load()})
+
+	sel, err := discover.PickWithTag("balancer-hash", "", "user-1")
+	require.NoError(t, err)
+	defer sel.Done(nil)
+	if hitsA.Load() == 10 {
+		assert.Equal(t, a, sel.Address)
+	} else {
+		assert.Equal(t, b, sel.Address)
+	}
+}
@@ -0,0 +1,142 @@
+// File:		json.go
+// Created by:	Hoven
+// Created on:	2026-10-17
+//
+// This file is part of the Example Project.
+//
+// (c) 2024 Example Corp. All rights reserved.
+
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/miebyte/goutils/ginutils"
+	"github.com/pkg/errors"
+)
+
+const maxErrorBodyBytes = 64 << 10
+
+// ResponseError 下游返回非 2xx 状态码或 ginutils.Ret 的 code 非 0
+//
+// 实现了 ginutils.ErrCoder, 可直接通过 ginutils.ReturnError 将错误码返回给上游。
+type ResponseError struct {
+	Service    string
+	StatusCode int
+	ErrCode    int
+	Message    string
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("httpclient: service(%s) status=%d code=%d message=%s", e.Service, e.StatusCode, e.ErrCode, e.Message)
+}
+
+func (e *ResponseError) Code() int {
+	return e.ErrCode
+}
+
+var _ ginutils.ErrCoder = (*ResponseError)(nil)
+
+// GetJSON 发送 GET 请求, 并将 ginutils.Ret 中的 data 解析为 T
+func GetJSON[T any](ctx context.Context, c *Client, path string) (T, error) {
+	return DoJSON[T](ctx, c, http.MethodGet, path, nil)
+}
+
+// PostJSON 将 body 编码为 JSON 发送 POST 请求, 并将 ginutils.Ret 中的 data 解析为 T
+func PostJSON[T any](ctx context.Context, c *Client, path string, body any) (T, error) {
+	return DoJSON[T](ctx, c, http.MethodPost, path, body)
+}
+
+// DoJSON 将 body 编码为 JSON 发送请求, 并将响应解析为 ginutils.Ret[T]
+//
+// 状态码非 2xx 或 code 非 0 时返回 *ResponseError, 响应为 204 或响应体为空时返回 T 的零值。
+func DoJSON[T any](ctx context.Context, c *Client, method, path string, body any) (T, error) {
+	var zero T
+
+	var reader io.Reader
+	if body != nil {
+		b, err := json.Marshal(body)
+		if err != nil {
+			return zero, errors.Wrap(err, "marshal body")
+		}
+		reader = bytes.NewReader(b)
+	}
+
+	req, err := c.NewRequest(ctx, method, path, reader)
+	if err != nil {
+		return zero, errors.Wrap(err, "new request")
+	}
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return zero, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return zero, c.responseError(resp)
+	}
+
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return zero, errors.Wrapf(err, "read response of service(%s)", c.service)
+	}
+	// 204 或空响应体没有 data
+	if resp.StatusCode == http.StatusNoContent || len(bytes.TrimSpace(b)) == 0 {
+		return zero, nil
+	}
+
+	var ret ginutils.Ret[T]
+	if err := json.Unmarshal(b, &ret); err != nil {
+		return zero, errors.Wrapf(err, "decode response of service(%s)", c.service)
+	}
+	if ret.Code != 0 {
+		return zero, &ResponseError{
+			Service:    c.service,
+			StatusCode: resp.StatusCode,
+			ErrCode:    ret.Code,
+			Message:    messageString(ret.Message),
+		}
+	}
+	return ret.Data, nil
+}
+
+// responseError 优先使用响应体中 ginutils.Ret 的 code 与 message, 否则使用响应体原文
+func (c *Client) responseError(resp *http.Response) *ResponseError {
+	e := &ResponseError{Service: c.service, StatusCode: resp.StatusCode, ErrCode: resp.StatusCode}
+
+	b, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodyBytes))
+	var ret ginutils.Ret[json.RawMessage]
+	if err := json.Unmarshal(b, &ret); err == nil && ret.Message != nil {
+		e.ErrCode = ret.Code
+		e.Message = messageString(ret.Message)
+		return e
+	}
+
+	e.Message = strings.TrimSpace(string(b))
+	if e.Message == "" {
+		e.Message = http.StatusText(resp.StatusCode)
+	}
+	return e
+}
+
+func messageString(message any) string {
+	if s, ok := message.(string); ok {
+		return s
+	}
+	if message == nil {
+		return ""
+	}
+	b, _ := json.Marshal(message)
+	return string(b)
+}
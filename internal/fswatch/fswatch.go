@@ -0,0 +1,35 @@
+// File:		fswatch.go
+// Created by:	Hoven
+// Created on:	2026-10-17
+//
+// This file is part of the Example Project.
+//
+// (c) 2024 Example Corp. All rights reserved.
+
+package fswatch
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// FileChanged 监听文件所在目录时, 判断事件是否表示 files 中的文件内容发生了变化
+//
+// Kubernetes 挂载的 ConfigMap/Secret 中文件是指向 ..data/<file> 的软链接,
+// 更新时替换 ..data 软链接, 文件本身不会产生事件, 因此 .. 开头的文件变更也视为文件变化。
+func FileChanged(ev fsnotify.Event, files ...string) bool {
+	if ev.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+		return false
+	}
+	if strings.HasPrefix(filepath.Base(ev.Name), "..") {
+		return true
+	}
+	for _, file := range files {
+		if ev.Name == file {
+			return true
+		}
+	}
+	return false
+}
@@ -55,6 +55,10 @@ func Enable(l level.Level) {
 	logger.Enable(l)
 }
 
+func GetLevel() level.Level {
+	return logger.Level()
+}
+
 func Error(msg string) { logger.Error(msg) }
 func Warn(msg string)  { logger.Warn(msg) }
 func Debug(msg string) { logger.Debug(msg) }
@@ -1,5 +1,10 @@
 package level
 
+import (
+	"fmt"
+	"strings"
+)
+
 type Level int
 
 const (
@@ -29,3 +34,18 @@ var AllLevels = []Level{
 	LevelWarn,
 	LevelError,
 }
+
+// ParseLevel 将 debug/info/warn/error(不区分大小写) 解析为日志级别
+func ParseLevel(s string) (Level, error) {
+	switch strings.ToUpper(strings.TrimSpace(s)) {
+	case "DEBUG":
+		return LevelDebug, nil
+	case "INFO":
+		return LevelInfo, nil
+	case "WARN", "WARNING":
+		return LevelWarn, nil
+	case "ERROR":
+		return LevelError, nil
+	}
+	return LevelInfo, fmt.Errorf("unknown log level: %q", s)
+}
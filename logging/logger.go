@@ -6,6 +6,7 @@ import (
 	"io"
 	"os"
 	"sync"
+	"sync/atomic"
 
 	"github.com/miebyte/goutils/logging/level"
 	"github.com/miebyte/goutils/utils"
@@ -19,7 +20,7 @@ type PrettyLogger struct {
 	Formatter  Formatter
 	module     string
 
-	level     level.Level
+	level     atomic.Int64
 	mu        MutexWrap
 	hooksMu   RWMutexWrap
 	hooks     LevelHook
@@ -45,10 +46,11 @@ func NewPrettyLogger(w io.Writer, opts ...PrettyLoggerOption) *PrettyLogger {
 		Out:        w,
 		WithSource: true,
 		Formatter:  new(TextFormatter),
-		level:      level.LevelInfo,
 		hooks:      make(LevelHook),
 	}
 
+	l.level.Store(int64(level.LevelInfo))
+
 	for _, opt := range opts {
 		opt(l)
 	}
@@ -85,11 +87,15 @@ func (l *PrettyLogger) releaseEntry(entry *Entry) {
 }
 
 func (l *PrettyLogger) IsLevelEnabled(level level.Level) bool {
-	return level >= l.level
+	return level >= l.Level()
 }
 
 func (l *PrettyLogger) Enable(lev level.Level) {
-	l.level = lev
+	l.level.Store(int64(lev))
+}
+
+func (l *PrettyLogger) Level() level.Level {
+	return level.Level(l.level.Load())
 }
 
 func (l *PrettyLogger) SetNoLock() {
@@ -111,7 +117,7 @@ func (l *PrettyLogger) SetFormatter(f Formatter) {
 }
 
 func (l *PrettyLogger) IsDebug() bool {
-	return l.level == level.LevelDebug
+	return l.Level() == level.LevelDebug
 }
 
 func (l *PrettyLogger) SetOutput(o io.Writer) {
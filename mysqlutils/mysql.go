@@ -9,6 +9,7 @@
 package mysqlutils
 
 import (
+	"context"
 	"fmt"
 	"time"
 
@@ -219,3 +220,18 @@ func (mp MysqlPool) GetDBWithResolver(routerConfig *DBRouterConfig) (*gorm.DB, e
 
 func (mp MysqlPool) Close() {
 }
+
+// HealthCheck 对连接池中的所有 mysql 执行 Ping, 可注册为 cores 的健康检查
+func (mp MysqlPool) HealthCheck(ctx context.Context) error {
+	for name, db := range mp {
+		sqlDB, err := db.DB()
+		if err != nil {
+			return errors.Wrapf(err, "get mysql(%s) db", name)
+		}
+
+		if err := sqlDB.PingContext(ctx); err != nil {
+			return errors.Wrapf(err, "ping mysql(%s)", name)
+		}
+	}
+	return nil
+}
@@ -19,8 +19,68 @@ func SendAPICounter() {
 }
 
 // SendAPIHistogram 发送接口访问耗时的监控
+// url 应为路由模板而非原始路径, 超过 SetAPIRouteLimit 上限的新路由会被归入 OverflowRoute
 func SendAPIHistogram(url string, processTime float64, statusCode int) {
-	APIHistogram.WithLabelValues(url, strconv.Itoa(statusCode)).Observe(processTime)
+	APIHistogram.WithLabelValues(routeLabel(url), strconv.Itoa(statusCode)).Observe(processTime)
+}
+
+// IncAPIInFlightGauge 接口请求开始处理
+func IncAPIInFlightGauge() {
+	APIInFlightGauge.Inc()
+}
+
+// DecAPIInFlightGauge 接口请求处理结束
+func DecAPIInFlightGauge() {
+	APIInFlightGauge.Dec()
+}
+
+// SendAPIRequestSizeHistogram 发送接口请求体大小的监控
+func SendAPIRequestSizeHistogram(url string, size float64) {
+	APIRequestSizeHistogram.WithLabelValues(routeLabel(url)).Observe(size)
+}
+
+// SendAPIResponseSizeHistogram 发送接口响应体大小的监控
+func SendAPIResponseSizeHistogram(url string, size float64) {
+	APIResponseSizeHistogram.WithLabelValues(routeLabel(url)).Observe(size)
+}
+
+// SendAPIShedCounter 发送接口请求被过载保护拒绝的监控
+func SendAPIShedCounter(reason string) {
+	APIShedCounter.WithLabelValues(reason).Inc()
+}
+
+// SendAPIConcurrencyLimitGauge 发送自适应并发限制当前上限的监控
+func SendAPIConcurrencyLimitGauge(limit float64) {
+	APIConcurrencyLimitGauge.Set(limit)
+}
+
+// SendWorkerRestartCounter 发送 worker 重启次数的监控
+func SendWorkerRestartCounter(worker, reason string) {
+	WorkerRestartCounter.WithLabelValues(worker, reason).Inc()
+}
+
+// SendWorkerPanicCounter 发送 worker panic 次数的监控
+func SendWorkerPanicCounter(worker string) {
+	WorkerPanicCounter.WithLabelValues(worker).Inc()
+}
+
+// SendHTTPClientHistogram 发送调用下游 http 服务耗时的监控, statusCode 为 0 表示请求未得到响应
+func SendHTTPClientHistogram(service, method string, statusCode int, processTime float64) {
+	HTTPClientHistogram.WithLabelValues(service, method, strconv.Itoa(statusCode)).Observe(processTime)
+}
+
+// SendHTTPClientRetryCounter 发送调用下游 http 服务重试次数的监控
+func SendHTTPClientRetryCounter(service, method string) {
+	HTTPClientRetryCounter.WithLabelValues(service, method).Inc()
+}
+
+// SendWorkerLeaderGauge 发送 leader worker 是否持有 leadership 的监控
+func SendWorkerLeaderGauge(worker string, leader bool) {
+	value := 0.0
+	if leader {
+		value = 1
+	}
+	WorkerLeaderGauge.WithLabelValues(worker).Set(value)
 }
 
 // SendCurrentTCPConnectionGauge 发送tcp连接数的监控
@@ -6,6 +6,8 @@ type ProModule string
 const (
 	APIMonitor    ProModule = "api_monitor"
 	ServerMonitor ProModule = "server_monitor"
+	WorkerMonitor ProModule = "worker_monitor"
+	ClientMonitor ProModule = "client_monitor"
 )
 
 func (p ProModule) String() string {
@@ -0,0 +1,89 @@
+package prometheusutils
+
+import (
+	"context"
+	"sync"
+)
+
+const (
+	// OverflowRoute 超过路由数量上限后新路由使用的标签值
+	OverflowRoute = "other"
+	// UnmatchedRoute 未匹配到任何路由(如 404)的请求使用的标签值
+	UnmatchedRoute = "unmatched"
+)
+
+var (
+	routeMu    sync.RWMutex
+	routeLimit = 200
+	routeSeen  = make(map[string]struct{})
+)
+
+type routeHolderKey struct{}
+
+type routeHolder struct {
+	mu    sync.Mutex
+	route string
+}
+
+// SetAPIRouteLimit 设置接口监控 url 标签的最大取值数量, 超出后归入 OverflowRoute, <= 0 表示不限制
+func SetAPIRouteLimit(limit int) {
+	routeMu.Lock()
+	routeLimit = limit
+	routeMu.Unlock()
+}
+
+// routeLabel 控制 url 标签基数, 已记录过的路由直接返回, 新路由在达到上限后返回 OverflowRoute
+func routeLabel(route string) string {
+	if route == "" {
+		return UnmatchedRoute
+	}
+
+	routeMu.RLock()
+	_, seen := routeSeen[route]
+	limit := routeLimit
+	routeMu.RUnlock()
+	if seen || limit <= 0 {
+		return route
+	}
+
+	routeMu.Lock()
+	defer routeMu.Unlock()
+
+	if _, seen := routeSeen[route]; seen {
+		return route
+	}
+	if len(routeSeen) >= routeLimit {
+		return OverflowRoute
+	}
+	routeSeen[route] = struct{}{}
+	return route
+}
+
+// WithRouteHolder 在 ctx 中放入路由模板容器, 供下游路由框架通过 SetRoute 回填匹配到的路由
+func WithRouteHolder(ctx context.Context) context.Context {
+	return context.WithValue(ctx, routeHolderKey{}, &routeHolder{})
+}
+
+// SetRoute 回填请求匹配到的路由模板(如 gin 的 FullPath), ctx 中不存在容器时忽略
+func SetRoute(ctx context.Context, route string) {
+	holder, ok := ctx.Value(routeHolderKey{}).(*routeHolder)
+	if !ok || route == "" {
+		return
+	}
+
+	holder.mu.Lock()
+	holder.route = route
+	holder.mu.Unlock()
+}
+
+// RouteFromContext 获取回填的路由模板
+func RouteFromContext(ctx context.Context) string {
+	holder, ok := ctx.Value(routeHolderKey{}).(*routeHolder)
+	if !ok {
+		return ""
+	}
+
+	holder.mu.Lock()
+	defer holder.mu.Unlock()
+	return holder.route
+}
@@ -50,17 +50,94 @@ var APICounter = promauto.With(defaultRegistry).NewCounter(
 	},
 )
 
-// APIHistogram 接口访问耗时的监控对象
-var APIHistogram = promauto.With(defaultRegistry).NewHistogramVec(
-	prometheus.HistogramOpts{
-		Name:        "api_histogram",
-		Help:        "api histogram monitor",
-		Buckets:     []float64{100, 500, 1000, 5000},
+var (
+	defaultAPIBuckets     = []float64{100, 500, 1000, 5000}
+	defaultAPISizeBuckets = prometheus.ExponentialBuckets(128, 4, 8)
+)
+
+// APIHistogram 接口访问耗时的监控对象, 单位 ms
+var APIHistogram = newAPIHistogram(defaultAPIBuckets)
+
+// APIInFlightGauge 正在处理中的接口请求数监控对象
+var APIInFlightGauge = promauto.With(defaultRegistry).NewGauge(
+	prometheus.GaugeOpts{
+		Name:        "api_in_flight_requests",
+		Help:        "api in flight requests",
+		ConstLabels: GetCommonLabelsMapWithModule(APIMonitor),
+	},
+)
+
+// APIRequestSizeHistogram 接口请求体大小的监控对象, 单位 byte
+var APIRequestSizeHistogram = newAPISizeHistogram("api_request_size_bytes", "api request size", defaultAPISizeBuckets)
+
+// APIResponseSizeHistogram 接口响应体大小的监控对象, 单位 byte
+var APIResponseSizeHistogram = newAPISizeHistogram("api_response_size_bytes", "api response size", defaultAPISizeBuckets)
+
+// APIShedCounter 过载保护拒绝的接口请求数监控对象
+var APIShedCounter = promauto.With(defaultRegistry).NewCounterVec(
+	prometheus.CounterOpts{
+		Name:        "api_shed_total",
+		Help:        "api requests shed by overload protection",
+		ConstLabels: GetCommonLabelsMapWithModule(APIMonitor),
+	},
+	[]string{"reason"},
+)
+
+// APIConcurrencyLimitGauge 自适应并发限制当前的上限值
+var APIConcurrencyLimitGauge = promauto.With(defaultRegistry).NewGauge(
+	prometheus.GaugeOpts{
+		Name:        "api_concurrency_limit",
+		Help:        "api adaptive concurrency limit",
 		ConstLabels: GetCommonLabelsMapWithModule(APIMonitor),
 	},
-	[]string{"url", "status_code"},
 )
 
+func newAPIHistogram(buckets []float64) *prometheus.HistogramVec {
+	return promauto.With(defaultRegistry).NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:        "api_histogram",
+			Help:        "api histogram monitor",
+			Buckets:     buckets,
+			ConstLabels: GetCommonLabelsMapWithModule(APIMonitor),
+		},
+		[]string{"url", "status_code"},
+	)
+}
+
+func newAPISizeHistogram(name, help string, buckets []float64) *prometheus.HistogramVec {
+	return promauto.With(defaultRegistry).NewHistogramVec(
+		prometheus.HistogramOpts{
+			Name:        name,
+			Help:        help,
+			Buckets:     buckets,
+			ConstLabels: GetCommonLabelsMapWithModule(APIMonitor),
+		},
+		[]string{"url"},
+	)
+}
+
+// SetAPIHistogramBuckets 设置接口耗时监控的分桶(单位 ms), 需在服务启动前调用
+func SetAPIHistogramBuckets(buckets ...float64) {
+	if len(buckets) == 0 {
+		return
+	}
+
+	defaultRegistry.Unregister(APIHistogram)
+	APIHistogram = newAPIHistogram(buckets)
+}
+
+// SetAPISizeBuckets 设置接口请求/响应体大小监控的分桶(单位 byte), 需在服务启动前调用
+func SetAPISizeBuckets(buckets ...float64) {
+	if len(buckets) == 0 {
+		return
+	}
+
+	defaultRegistry.Unregister(APIRequestSizeHistogram)
+	defaultRegistry.Unregister(APIResponseSizeHistogram)
+	APIRequestSizeHistogram = newAPISizeHistogram("api_request_size_bytes", "api request size", buckets)
+	APIResponseSizeHistogram = newAPISizeHistogram("api_response_size_bytes", "api response size", buckets)
+}
+
 // TCPConnectionGauge 当前tcp连接数监控对象
 var TCPConnectionGauge = promauto.With(defaultRegistry).NewGauge(
 	prometheus.GaugeOpts{
@@ -78,3 +155,54 @@ var RemoteConnectionGauge = promauto.With(defaultRegistry).NewGauge(
 		ConstLabels: GetCommonLabelsMapWithModule(ServerMonitor),
 	},
 )
+
+// WorkerRestartCounter worker 重启次数监控对象
+var WorkerRestartCounter = promauto.With(defaultRegistry).NewCounterVec(
+	prometheus.CounterOpts{
+		Name:        "worker_restart_total",
+		Help:        "worker restart counter",
+		ConstLabels: GetCommonLabelsMapWithModule(WorkerMonitor),
+	},
+	[]string{"worker", "reason"},
+)
+
+// WorkerPanicCounter worker panic 次数监控对象
+var WorkerPanicCounter = promauto.With(defaultRegistry).NewCounterVec(
+	prometheus.CounterOpts{
+		Name:        "worker_panic_total",
+		Help:        "worker panic counter",
+		ConstLabels: GetCommonLabelsMapWithModule(WorkerMonitor),
+	},
+	[]string{"worker"},
+)
+
+// HTTPClientHistogram 调用下游 http 服务的耗时监控对象, 单位 ms
+var HTTPClientHistogram = promauto.With(defaultRegistry).NewHistogramVec(
+	prometheus.HistogramOpts{
+		Name:        "http_client_histogram",
+		Help:        "http client request histogram",
+		Buckets:     defaultAPIBuckets,
+		ConstLabels: GetCommonLabelsMapWithModule(ClientMonitor),
+	},
+	[]string{"service", "method", "status_code"},
+)
+
+// HTTPClientRetryCounter 调用下游 http 服务的重试次数监控对象
+var HTTPClientRetryCounter = promauto.With(defaultRegistry).NewCounterVec(
+	prometheus.CounterOpts{
+		Name:        "http_client_retry_total",
+		Help:        "http client retry counter",
+		ConstLabels: GetCommonLabelsMapWithModule(ClientMonitor),
+	},
+	[]string{"service", "method"},
+)
+
+// WorkerLeaderGauge leader worker 是否持有 leadership, 1 表示持有
+var WorkerLeaderGauge = promauto.With(defaultRegistry).NewGaugeVec(
+	prometheus.GaugeOpts{
+		Name:        "worker_leader",
+		Help:        "leader worker holds leadership or not",
+		ConstLabels: GetCommonLabelsMapWithModule(WorkerMonitor),
+	},
+	[]string{"worker"},
+)
@@ -1,6 +1,7 @@
 package redisutils
 
 import (
+	"context"
 	"slices"
 	"sync/atomic"
 
@@ -134,3 +135,20 @@ func (rp *RedisPool) Close() {
 func (rp *RedisPool) IsClosed() bool {
 	return rp.closed.Load()
 }
+
+// HealthCheck 对已建立连接的 redis 执行 PING, 可注册为 cores 的健康检查
+func (rp *RedisPool) HealthCheck(ctx context.Context) error {
+	if rp.IsClosed() {
+		return errRedisPoolClosed
+	}
+
+	var err error
+	rp.pools.Range(func(name string, client *RedisClient) bool {
+		if e := client.Ping(ctx).Err(); e != nil {
+			err = errors.Wrapf(e, "ping redis(%s)", name)
+			return false
+		}
+		return true
+	})
+	return err
+}